@@ -0,0 +1,171 @@
+package addresses
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+type AddressDetails struct {
+	database.PostalAddress
+	IsDefaultShipping bool `json:"is_default_shipping" example:"true"`
+	IsDefaultBilling  bool `json:"is_default_billing" example:"true"`
+}
+
+// GetMyAddresses godoc
+// @Summary List my addresses
+// @Description List the addresses in the authenticated user's address book, default addresses first
+// @Tags addresses
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Addresses retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /users/addresses [get]
+func GetMyAddresses(c *gin.Context) {
+	addresses := []database.Address{}
+	err := database.DB.Where("user_id = ?", c.GetUint("userId")).
+		Order("is_default_shipping desc, is_default_billing desc, id asc").Find(&addresses).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting addresses"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "addresses fetched successfully", "addresses": addresses})
+}
+
+// GetMyAddress godoc
+// @Summary Get one of my addresses
+// @Description Get an address from the authenticated user's address book
+// @Tags addresses
+// @Produce json
+// @Param id path string true "Address ID"
+// @Success 200 {object} map[string]interface{} "Address retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Address not found"
+// @Security BearerAuth
+// @Router /users/addresses/{id} [get]
+func GetMyAddress(c *gin.Context) {
+	var address database.Address
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("userId")).First(&address).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "address not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "address fetched successfully", "address": address})
+}
+
+// CreateAddress godoc
+// @Summary Add an address
+// @Description Add an address to the authenticated user's address book. country is an ISO 3166-1 alpha-2 code; the postal code and region are checked against the country's format where it is known. The first address becomes the default shipping and billing address, and setting a default flag takes it from the previous default
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param address body AddressDetails true "Address data"
+// @Success 201 {object} map[string]interface{} "Address created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid address"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /users/addresses [post]
+func CreateAddress(c *gin.Context) {
+	var addressDetails AddressDetails
+	if err := c.ShouldBindJSON(&addressDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if err := utils.NormalizeAddress(&addressDetails.PostalAddress); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userId := c.GetUint("userId")
+	address := database.Address{UserID: userId, PostalAddress: addressDetails.PostalAddress}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&database.Address{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+			return err
+		}
+		address.IsDefaultShipping = addressDetails.IsDefaultShipping || count == 0
+		address.IsDefaultBilling = addressDetails.IsDefaultBilling || count == 0
+		if err := tx.Create(&address).Error; err != nil {
+			return err
+		}
+		return utils.ClearDefaultAddresses(tx, userId, address.ID, address.IsDefaultShipping, address.IsDefaultBilling)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the address"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "address created successfully", "address": address})
+}
+
+// UpdateAddress godoc
+// @Summary Update an address
+// @Description Replace an address in the authenticated user's address book. Orders already placed keep the address they were placed with. Clearing a default flag leaves the user without that default
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param id path string true "Address ID"
+// @Param address body AddressDetails true "Address data"
+// @Success 200 {object} map[string]interface{} "Address updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid address"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Address not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /users/addresses/{id} [put]
+func UpdateAddress(c *gin.Context) {
+	var address database.Address
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("userId")).First(&address).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "address not found"})
+		return
+	}
+	var addressDetails AddressDetails
+	if err := c.ShouldBindJSON(&addressDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if err := utils.NormalizeAddress(&addressDetails.PostalAddress); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	address.PostalAddress = addressDetails.PostalAddress
+	address.IsDefaultShipping = addressDetails.IsDefaultShipping
+	address.IsDefaultBilling = addressDetails.IsDefaultBilling
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&address).Error; err != nil {
+			return err
+		}
+		return utils.ClearDefaultAddresses(tx, address.UserID, address.ID, address.IsDefaultShipping, address.IsDefaultBilling)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the address"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "address updated successfully", "address": address})
+}
+
+// DeleteAddress godoc
+// @Summary Delete an address
+// @Description Remove an address from the authenticated user's address book. Orders already placed keep their copy of it
+// @Tags addresses
+// @Produce json
+// @Param id path string true "Address ID"
+// @Success 200 {object} map[string]interface{} "Address deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Address not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /users/addresses/{id} [delete]
+func DeleteAddress(c *gin.Context) {
+	var address database.Address
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("userId")).First(&address).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "address not found"})
+		return
+	}
+	if err := database.DB.Delete(&address).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the address"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "address deleted successfully"})
+}
@@ -1,148 +1,539 @@
 package carts
 
 import (
+	"errors"
 	"fmt"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/middleware"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+var errRemoveTooMany = errors.New("Cannot remove more items than exist in cart!")
+
 type AddToCart struct {
 	ProductId uint `json:"productId" example:"1"`
-	CartId    uint `json:"cartId" example:"1"`
+	VariantId uint `json:"variantId" example:"0"`
 	Quantity  int  `json:"quantity" example:"2"`
 }
 type RemoveItemFromCartDtls struct {
 	ProductId uint `json:"productId" example:"1"`
+	VariantId uint `json:"variantId" example:"0"`
 	Quantity  int  `json:"quantity" example:"1"`
 }
+type CartItemView struct {
+	ID                uint              `json:"id" example:"1"`
+	ProductId         uint              `json:"product_id" example:"1"`
+	VariantId         uint              `json:"variant_id,omitempty" example:"1"`
+	SKU               string            `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Attributes        map[string]string `json:"attributes,omitempty"`
+	Name              string            `json:"name" example:"iPhone 15"`
+	Quantity          int               `json:"quantity" example:"2"`
+	UnitPrice         database.Money    `json:"unit_price"`
+	LineTotal         database.Money    `json:"line_total"`
+	StockQty          int               `json:"stock_qty" example:"50"`
+	AvailableQty      int               `json:"available_qty" example:"48"`
+	ReservedUntil     *time.Time        `json:"reserved_until,omitempty"`
+	InsufficientStock bool              `json:"insufficient_stock" example:"false"`
+	ProductDeleted    bool              `json:"product_deleted" example:"false"`
+}
+type AppliedCoupon struct {
+	Code         string         `json:"code" example:"SUMMER10"`
+	Type         string         `json:"type" example:"PERCENTAGE"`
+	Description  string         `json:"description" example:"10% off everything"`
+	Discount     database.Money `json:"discount"`
+	FreeShipping bool           `json:"free_shipping" example:"false"`
+}
+type CartView struct {
+	ID        uint           `json:"id" example:"1"`
+	Currency  string         `json:"currency" example:"USD"`
+	Items     []CartItemView `json:"items"`
+	ItemCount int            `json:"item_count" example:"2"`
+	Subtotal  database.Money `json:"subtotal"`
+	Coupon    *AppliedCoupon `json:"coupon,omitempty"`
+	Discount  database.Money `json:"discount"`
+	Total     database.Money `json:"total"`
+	Warnings  []string       `json:"warnings"`
+}
+type CouponCode struct {
+	Code string `json:"code" example:"SUMMER10"`
+}
+type ShippingOptionView struct {
+	MethodID    uint           `json:"method_id" example:"1"`
+	Code        string         `json:"code" example:"standard"`
+	Name        string         `json:"name" example:"Standard delivery"`
+	Carrier     string         `json:"carrier" example:"UPS"`
+	Description string         `json:"description" example:"3 to 5 business days"`
+	Price       database.Money `json:"price"`
+}
 
 // AddItemToCart godoc
 // @Summary Add item to cart
-// @Description Add a product to the user's shopping cart
+// @Description Add a product to the user's shopping cart, or to a guest cart without an access token, and hold its stock for RESERVATION_TTL (15 minutes by default). Adding more of an item restarts the hold. Products sold in variants require variantId. The first item a guest adds creates a guest cart, whose token is returned as cart_token, in the X-Cart-Token header and in the cart_token cookie; send it back with the X-Cart-Token header or the cookie to use the cart
 // @Tags carts
 // @Accept json
 // @Produce json
 // @Param item body AddToCart true "Item to add to cart"
+// @Param X-Cart-Token header string false "Guest cart token, also accepted as the cart_token cookie"
 // @Success 200 {object} map[string]interface{} "Item added successfully"
-// @Failure 400 {object} map[string]interface{} "Bad request - validation error or insufficient stock"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error or not enough available stock"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
-// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 404 {object} map[string]interface{} "User, product or variant not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /carts/add [post]
 func AddItemToCart(c *gin.Context) {
 	var addToCart AddToCart
-	userId, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+	if err := c.BindJSON(&addToCart); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error while binding the request body"})
 		return
 	}
-	uid, ok := userId.(uint)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+	if addToCart.ProductId == 0 || addToCart.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "productId and a positive quantity are required"})
 		return
 	}
-	if err := c.BindJSON(&addToCart); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "error while binding the request body"})
+	stockItem, err := utils.ResolveStockItem(database.DB, addToCart.ProductId, addToCart.VariantId)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrProductNotFound), errors.Is(err, utils.ErrVariantNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, utils.ErrVariantRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
-	if addToCart.CartId == 0 || addToCart.ProductId == 0 || addToCart.Quantity == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "all details are required"})
+	cart, err := currentCart(c)
+	cartToken := ""
+	if errors.Is(err, utils.ErrCartNotFound) {
+		cart, cartToken, err = createCart(c)
+	}
+	if err != nil {
+		respondCartError(c, err)
 		return
 	}
-	var product database.Product
-	if err := database.DB.First(&product, addToCart.ProductId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+	var cartItem database.CartItem
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("cart_id = ? and product_id = ? and variant_id = ?", cart.ID, addToCart.ProductId, stockItem.VariantID()).First(&cartItem).Error
+		if err == gorm.ErrRecordNotFound {
+			cartItem = database.CartItem{CartId: cart.ID, ProductId: addToCart.ProductId, VariantId: stockItem.VariantID()}
+		} else if err != nil {
+			return err
+		}
+		cartItem.Quantity += addToCart.Quantity
+		if err := tx.Save(&cartItem).Error; err != nil {
+			return err
+		}
+		// Hold stock for the item's whole quantity and restart its expiry.
+		return utils.ReserveCartItem(tx, cartItem)
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInsufficientStock) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fmt.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while adding item to cart"})
 		return
 	}
-	if product.StockQty < addToCart.Quantity {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "product in stock is not enough"})
+	response := gin.H{"message": "item added successfully", "cart_id": cart.ID}
+	if cartToken != "" {
+		response["cart_token"] = cartToken
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMyCart godoc
+// @Summary View my cart
+// @Description Get the user's cart, or the guest cart named by the cart token, with current prices, line totals, subtotal, the applied coupon's discount and total, how long each item's stock is held and warnings for items that are out of stock or no longer sold and for a coupon that no longer applies
+// @Tags carts
+// @Produce json
+// @Param X-Currency header string false "Currency to show prices in (default the base currency), also accepted as the currency query parameter"
+// @Param X-Cart-Token header string false "Guest cart token, also accepted as the cart_token cookie"
+// @Success 200 {object} map[string]interface{} "Cart retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /carts/mine [get]
+func GetMyCart(c *gin.Context) {
+	cart, err := currentCart(c)
+	if err != nil {
+		if errors.Is(err, utils.ErrCartNotFound) {
+			c.JSON(http.StatusOK, gin.H{"message": "cart is empty", "cart": CartView{Items: []CartItemView{}, Warnings: []string{}}})
+			return
+		}
+		respondCartError(c, err)
 		return
 	}
-	var eCart database.Cart
-	if err := database.DB.First(&eCart, addToCart.CartId).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			var cart database.Cart
-			cart.UserId = uid
-			if err := database.DB.Create(&cart).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create a cart"})
+	view, lines, err := priceCart(cart, middleware.RequestCurrency(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if cart.CouponID != nil {
+		// DeleteCoupon clears the coupon from carts, so it is always found.
+		var coupon database.Coupon
+		if err := database.DB.First(&coupon, *cart.CouponID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting the cart's coupon"})
+			return
+		}
+		if err := applyCoupon(&view, coupon, c.GetUint("userId"), lines); err != nil {
+			if !utils.IsCouponRejection(err) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
+			view.Warnings = append(view.Warnings, fmt.Sprintf("coupon %s no longer applies: %v", coupon.Code, err))
 		}
 	}
-	var existingCartItem database.CartItem
-	var cartItem database.CartItem
-	if err := database.DB.Where("cart_id = ? and product_id = ?", eCart.ID, addToCart.ProductId).First(&existingCartItem).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			cartItem.CartId = eCart.ID
-			cartItem.ProductId = addToCart.ProductId
-			cartItem.Quantity = addToCart.Quantity
-			if err := database.DB.Create(&cartItem).Error; err != nil {
-				fmt.Println(err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while adding item to cart"})
-				return
-			}
-			var user database.User
-			if err := database.DB.First(&user, userId).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-				return
-			}
-			if user.Cart == 0 {
-				user.Name = user.Name
-				user.Password = user.Password
-				user.Email = user.Email
-				user.Cart = eCart.ID
-				if err := database.DB.Save(&user).Error; err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the user's cart"})
-					return
-				}
-			}
-		} else {
-			fmt.Print(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while checking the cart item"})
+	c.JSON(http.StatusOK, gin.H{"message": "cart fetched successfully", "cart": view})
+}
+
+// ApplyCoupon godoc
+// @Summary Apply a coupon to my cart
+// @Description Apply a discount code to the user's cart or the guest cart, replacing any coupon applied before. The code is checked against the cart's items, minimum spend, expiry and usage limits, and checked again when the order is placed
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param coupon body CouponCode true "Coupon code"
+// @Param X-Currency header string false "Currency to show prices in (default the base currency), also accepted as the currency query parameter"
+// @Param X-Cart-Token header string false "Guest cart token, also accepted as the cart_token cookie"
+// @Success 200 {object} map[string]interface{} "Coupon applied successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - coupon cannot be used on this cart"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User, cart or coupon not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /carts/coupon [post]
+func ApplyCoupon(c *gin.Context) {
+	var couponCode CouponCode
+	if err := c.ShouldBindJSON(&couponCode); err != nil || couponCode.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	cart, err := currentCart(c)
+	if err != nil {
+		respondCartError(c, err)
+		return
+	}
+	coupon, err := utils.FindCoupon(database.DB, couponCode.Code)
+	if err != nil {
+		if errors.Is(err, utils.ErrCouponNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	view, lines, err := priceCart(cart, middleware.RequestCurrency(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyCoupon(&view, coupon, c.GetUint("userId"), lines); err != nil {
+		if utils.IsCouponRejection(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.DB.Model(&cart).Update("coupon_id", coupon.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while applying the coupon"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupon applied successfully", "cart": view})
+}
+
+// RemoveCoupon godoc
+// @Summary Remove the coupon from my cart
+// @Description Remove the discount code applied to the user's cart or the guest cart
+// @Tags carts
+// @Produce json
+// @Param X-Cart-Token header string false "Guest cart token, also accepted as the cart_token cookie"
+// @Success 200 {object} map[string]interface{} "Coupon removed successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User or cart not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /carts/coupon [delete]
+func RemoveCoupon(c *gin.Context) {
+	cart, err := currentCart(c)
+	if err != nil {
+		respondCartError(c, err)
+		return
+	}
+	if err := database.DB.Model(&cart).Update("coupon_id", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while removing the coupon"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupon removed successfully"})
+}
+
+// GetShippingOptions godoc
+// @Summary Quote shipping for my cart
+// @Description List the shipping methods that can ship the cart to an address from the user's address book (the default shipping address unless address_id is given), or to a country, cheapest first, with what each would cost. A free shipping coupon on the cart makes every method free. Pass one as shipping_method_id when placing the order
+// @Tags carts
+// @Produce json
+// @Param address_id query int false "Address to ship to (default the user's default shipping address)"
+// @Param country query string false "ISO 3166-1 alpha-2 country to ship to instead of an address, required for guest carts"
+// @Param X-Currency header string false "Currency to show prices in (default the base currency), also accepted as the currency query parameter"
+// @Param X-Cart-Token header string false "Guest cart token, also accepted as the cart_token cookie"
+// @Success 200 {object} map[string]interface{} "Shipping options retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - no shipping address or country"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User, cart or address not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /carts/shipping-options [get]
+func GetShippingOptions(c *gin.Context) {
+	userId := c.GetUint("userId")
+	response := gin.H{"message": "shipping options fetched successfully"}
+	country := strings.ToUpper(strings.TrimSpace(c.Query("country")))
+	if country != "" {
+		if !utils.ValidCountryCode(country) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", country)})
 			return
 		}
+	} else if userId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "country is required for guest carts"})
+		return
 	} else {
-		existingCartItem.Quantity += addToCart.Quantity
-		if err := database.DB.Save(&existingCartItem).Error; err != nil {
-			fmt.Print(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update cart item quantity"})
+		addressId, err := strconv.ParseUint(c.DefaultQuery("address_id", "0"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address_id"})
+			return
+		}
+		address, _, err := utils.ResolveOrderAddresses(database.DB, userId, uint(addressId), 0)
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.ErrShippingAddressUnset):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, utils.ErrAddressNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		country = address.Country
+		response["address_id"] = address.ID
+	}
+	cart, err := currentCart(c)
+	if err != nil {
+		respondCartError(c, err)
+		return
+	}
+	currency := middleware.RequestCurrency(c)
+	view, lines, err := priceCart(cart, currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if cart.CouponID != nil {
+		var coupon database.Coupon
+		if err := database.DB.First(&coupon, *cart.CouponID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting the cart's coupon"})
 			return
 		}
+		if err := applyCoupon(&view, coupon, userId, lines); err != nil && !utils.IsCouponRejection(err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	priceList, err := utils.LoadPriceList(database.DB, currency, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	parcel := utils.Parcel{Value: priceList.ToBase(view.Total)}
+	for _, item := range view.Items {
+		if item.ProductDeleted {
+			continue
+		}
+		var product database.Product
+		if err := database.DB.First(&product, item.ProductId).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting cart products"})
+			return
+		}
+		parcel.Add(product, item.Quantity)
+	}
+	options, err := utils.ShippingOptions(database.DB, country, parcel)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	freeShipping := view.Coupon != nil && view.Coupon.FreeShipping
+	views := make([]ShippingOptionView, 0, len(options))
+	for _, option := range options {
+		price := priceList.FromBase(option.Price)
+		if freeShipping {
+			price = database.NewMoney(0, priceList.Currency)
+		}
+		views = append(views, ShippingOptionView{
+			MethodID:    option.Method.ID,
+			Code:        option.Method.Code,
+			Name:        option.Method.Name,
+			Carrier:     option.Method.Carrier,
+			Description: option.Method.Description,
+			Price:       price,
+		})
+	}
+	response["shipping_options"] = views
+	c.JSON(http.StatusOK, response)
+}
+
+// currentCart returns the signed-in user's cart, or the guest cart named by
+// the request's cart token, with its items. Without one it returns
+// utils.ErrCartNotFound.
+func currentCart(c *gin.Context) (database.Cart, error) {
+	var cart database.Cart
+	var err error
+	if userId := c.GetUint("userId"); userId != 0 {
+		cart, err = utils.UserCart(database.DB, userId, false)
+	} else {
+		cart, err = utils.GuestCart(database.DB, middleware.CartToken(c))
+		if errors.Is(err, utils.ErrInvalidCartToken) {
+			err = utils.ErrCartNotFound
+		}
+	}
+	if err != nil {
+		return cart, err
+	}
+	if err := database.DB.Where("cart_id = ?", cart.ID).Order("id").Find(&cart.CartItems).Error; err != nil {
+		return cart, errors.New("error while getting the cart")
+	}
+	return cart, nil
+}
+
+// createCart creates a cart for the signed-in user, or a guest cart whose
+// token is handed to the client and returned.
+func createCart(c *gin.Context) (database.Cart, string, error) {
+	if userId := c.GetUint("userId"); userId != 0 {
+		cart, err := utils.UserCart(database.DB, userId, true)
+		return cart, "", err
+	}
+	cart, token, err := utils.CreateGuestCart(database.DB)
+	if err != nil {
+		return cart, "", err
+	}
+	middleware.SetCartToken(c, token)
+	return cart, token, nil
+}
+
+func respondCartError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrCartNotFound), errors.Is(err, utils.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// priceCart builds the view of a cart's items at current prices in currency
+// and returns the lines a coupon is evaluated against. Items that are no
+// longer sold are listed with a warning but left out of the totals.
+func priceCart(cart database.Cart, currency string) (CartView, []utils.CouponLine, error) {
+	zero := database.NewMoney(0, currency)
+	view := CartView{ID: cart.ID, Currency: currency, Items: []CartItemView{}, Subtotal: zero, Discount: zero, Warnings: []string{}}
+	productIds := make([]uint, 0, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		productIds = append(productIds, item.ProductId)
+	}
+	priceList, err := utils.LoadPriceList(database.DB, currency, productIds)
+	if err != nil {
+		return view, nil, err
+	}
+	var lines []utils.CouponLine
+	for _, item := range cart.CartItems {
+		itemView := CartItemView{ID: item.ID, ProductId: item.ProductId, VariantId: item.VariantId, Quantity: item.Quantity}
+		stockItem, err := utils.ResolveStockItem(database.DB, item.ProductId, item.VariantId)
+		if err != nil {
+			if !errors.Is(err, utils.ErrProductNotFound) && !errors.Is(err, utils.ErrVariantNotFound) && !errors.Is(err, utils.ErrVariantRequired) {
+				return view, nil, errors.New("error while getting cart products")
+			}
+			itemView.ProductDeleted = true
+			view.Warnings = append(view.Warnings, fmt.Sprintf("product %d is no longer available and will not be ordered", item.ProductId))
+			view.Items = append(view.Items, itemView)
+			continue
+		}
+		if stockItem.Variant != nil {
+			itemView.SKU = stockItem.Variant.SKU
+			itemView.Attributes = stockItem.Variant.Attributes
+		}
+		itemView.Name = stockItem.Label()
+		itemView.UnitPrice = priceList.UnitPrice(stockItem)
+		itemView.LineTotal = itemView.UnitPrice.Mul(item.Quantity)
+		itemView.StockQty = stockItem.StockQty()
+		itemView.AvailableQty, err = utils.AvailableForCartItem(database.DB, item, itemView.StockQty)
+		if err != nil {
+			return view, nil, err
+		}
+		reservation, err := utils.CartItemReservation(database.DB, item.ID)
+		if err != nil {
+			return view, nil, errors.New("error while getting stock reservations")
+		}
+		if reservation != nil {
+			itemView.ReservedUntil = &reservation.ExpiresAt
+		}
+		if itemView.AvailableQty < item.Quantity {
+			itemView.InsufficientStock = true
+			view.Warnings = append(view.Warnings, fmt.Sprintf("only %d of %s left in stock", itemView.AvailableQty, itemView.Name))
+		}
+		view.ItemCount += item.Quantity
+		view.Subtotal = view.Subtotal.Add(itemView.LineTotal)
+		view.Items = append(view.Items, itemView)
+		lines = append(lines, utils.CouponLine{ProductID: item.ProductId, Quantity: item.Quantity, UnitPrice: itemView.UnitPrice})
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "item added successfully"})
+	view.Total = view.Subtotal
+	return view, lines, nil
+}
+
+// applyCoupon evaluates the coupon against the cart's lines and adds its
+// discount to the view.
+func applyCoupon(view *CartView, coupon database.Coupon, userID uint, lines []utils.CouponLine) error {
+	discount, err := utils.EvaluateCoupon(database.DB, coupon, userID, lines)
+	if err != nil {
+		return err
+	}
+	view.Discount = view.Discount.Add(discount.Amount)
+	view.Coupon = &AppliedCoupon{
+		Code:         coupon.Code,
+		Type:         coupon.Type,
+		Description:  coupon.Description,
+		Discount:     view.Discount,
+		FreeShipping: discount.FreeShipping,
+	}
+	view.Total = view.Subtotal.Sub(view.Discount)
+	return nil
 }
 
 // RemoveItemToCart godoc
 // @Summary Remove item from cart
-// @Description Remove a product from the user's shopping cart
+// @Description Remove a product from the user's shopping cart or the guest cart
 // @Tags carts
 // @Accept json
 // @Produce json
 // @Param item body RemoveItemFromCartDtls true "Item to remove from cart"
+// @Param X-Cart-Token header string false "Guest cart token, also accepted as the cart_token cookie"
 // @Success 200 {object} map[string]interface{} "Cart item updated successfully"
-// @Failure 400 {object} map[string]interface{} "Bad request - cannot remove more than available"
+// @Failure 400 {object} map[string]interface{} "Bad request - quantity not positive or more than in the cart"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 404 {object} map[string]interface{} "User, cart, or cart item not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /carts/remove [delete]
 func RemoveItemToCart(c *gin.Context) {
-	userId, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
-		return
-	}
-	var user database.User
-	if err := database.DB.First(&user, userId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-		return
-	}
-	var cart database.Cart
-	if err := database.DB.First(&cart, user.Cart).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cart not found"})
+	cart, err := currentCart(c)
+	if err != nil {
+		respondCartError(c, err)
 		return
 	}
 	var removeItemFromCartDtls RemoveItemFromCartDtls
@@ -150,31 +541,42 @@ func RemoveItemToCart(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while binding the request body"})
 		return
 	}
-	var cartItem database.CartItem
-	if err := database.DB.Where("cart_id = ? AND product_id = ?", cart.ID, removeItemFromCartDtls.ProductId).First(&cartItem).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving cart item"})
-		}
+	if removeItemFromCartDtls.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a positive quantity is required"})
 		return
 	}
-
-	if cartItem.Quantity == 1 || cartItem.Quantity == removeItemFromCartDtls.Quantity {
-		// Delete the cart item
-		if err := database.DB.Delete(&cartItem).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove item from cart!"})
-			return
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var cartItem database.CartItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("cart_id = ? AND product_id = ? AND variant_id = ?", cart.ID, removeItemFromCartDtls.ProductId, removeItemFromCartDtls.VariantId).First(&cartItem).Error; err != nil {
+			return err
+		}
+		if cartItem.Quantity == 1 || cartItem.Quantity == removeItemFromCartDtls.Quantity {
+			// Delete the cart item
+			if err := tx.Delete(&cartItem).Error; err != nil {
+				return err
+			}
+			return utils.ReleaseReservation(tx, cartItem.ID)
+		}
+		if cartItem.Quantity < removeItemFromCartDtls.Quantity {
+			return errRemoveTooMany
 		}
-	} else if cartItem.Quantity > removeItemFromCartDtls.Quantity {
 		// Reduce the cart item's quantity
 		cartItem.Quantity -= removeItemFromCartDtls.Quantity
-		if err := database.DB.Save(&cartItem).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item's quantity!"})
-			return
+		if err := tx.Save(&cartItem).Error; err != nil {
+			return err
+		}
+		return utils.ShrinkReservation(tx, cartItem.ID, cartItem.Quantity)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+		case errors.Is(err, errRemoveTooMany):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			fmt.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove item from cart!"})
 		}
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove more items than exist in cart!"})
 		return
 	}
 
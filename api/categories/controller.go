@@ -0,0 +1,254 @@
+package categories
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+type CategoryDetails struct {
+	Name        string `json:"name" example:"Phones"`
+	Description string `json:"description" example:"Smartphones and accessories"`
+	ParentID    *uint  `json:"parent_id" example:"1"`
+}
+
+// CreateCategory godoc
+// @Summary Create a category
+// @Description Create a category, optionally nested under a parent category (requires products:write)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body CategoryDetails true "Category data"
+// @Success 201 {object} map[string]interface{} "Category created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error, unknown parent or category already exists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /categories [post]
+func CreateCategory(c *gin.Context) {
+	var categoryDetails CategoryDetails
+	if err := c.ShouldBindJSON(&categoryDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	category := database.Category{
+		Name:        strings.TrimSpace(categoryDetails.Name),
+		Description: categoryDetails.Description,
+	}
+	if category.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category name is required"})
+		return
+	}
+	if categoryDetails.ParentID != nil && *categoryDetails.ParentID != 0 {
+		var parent database.Category
+		if err := database.DB.First(&parent, *categoryDetails.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parent category not found"})
+			return
+		}
+		category.ParentID = &parent.ID
+	}
+	if !nameAvailable(c, category) {
+		return
+	}
+	if err := database.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the category"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "category created successfully", "category": category})
+}
+
+// GetAllCategories godoc
+// @Summary Get all categories
+// @Description List every category as a flat list ordered by name
+// @Tags categories
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Categories retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /categories/all [get]
+func GetAllCategories(c *gin.Context) {
+	var categories []database.Category
+	if err := database.DB.Order("name").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting categories"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "categories fetched successfully", "categories": categories})
+}
+
+// GetCategoryTree godoc
+// @Summary Get the category tree
+// @Description List every category nested under its parent, siblings ordered by name
+// @Tags categories
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Category tree retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /categories/tree [get]
+func GetCategoryTree(c *gin.Context) {
+	var categories []database.Category
+	if err := database.DB.Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting categories"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category tree fetched successfully", "categories": utils.BuildCategoryTree(categories)})
+}
+
+// GetCategory godoc
+// @Summary Get a category
+// @Description Retrieve a category with its direct subcategories
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} map[string]interface{} "Category retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /categories/{id} [get]
+func GetCategory(c *gin.Context) {
+	var category database.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	var children []database.Category
+	if err := database.DB.Where("parent_id = ?", category.ID).Order("name").Find(&children).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting subcategories"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category fetched successfully", "category": category, "children": children})
+}
+
+// UpdateCategory godoc
+// @Summary Update a category
+// @Description Rename, describe or move a category. A parent_id of 0 moves it to the top level; a category cannot be moved below itself or one of its descendants (requires products:write)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param category body CategoryDetails true "Category update data"
+// @Success 200 {object} map[string]interface{} "Category updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - unknown parent, cycle or duplicate name"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /categories/{id} [put]
+func UpdateCategory(c *gin.Context) {
+	var category database.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	var categoryDetails CategoryDetails
+	if err := c.ShouldBindJSON(&categoryDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if name := strings.TrimSpace(categoryDetails.Name); name != "" {
+		category.Name = name
+	}
+	if categoryDetails.Description != "" {
+		category.Description = categoryDetails.Description
+	}
+	if categoryDetails.ParentID != nil {
+		if *categoryDetails.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			var parent database.Category
+			if err := database.DB.First(&parent, *categoryDetails.ParentID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent category not found"})
+				return
+			}
+			descendants, err := utils.CategoryDescendantIDs(category.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting categories"})
+				return
+			}
+			for _, id := range descendants {
+				if id == parent.ID {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "a category cannot be moved below itself or one of its subcategories"})
+					return
+				}
+			}
+			category.ParentID = &parent.ID
+		}
+	}
+	if !nameAvailable(c, category) {
+		return
+	}
+	if err := database.DB.Save(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the category"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category updated successfully", "category": category})
+}
+
+// DeleteCategory godoc
+// @Summary Delete a category
+// @Description Delete a category that has no subcategories. Products keep existing but are no longer in the category (requires products:write)
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} map[string]interface{} "Category deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Conflict - category has subcategories"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /categories/{id} [delete]
+func DeleteCategory(c *gin.Context) {
+	var category database.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	var children int64
+	if err := database.DB.Model(&database.Category{}).Where("parent_id = ?", category.ID).Count(&children).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting subcategories"})
+		return
+	}
+	if children > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "move or delete the subcategories first"})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM product_categories WHERE category_id = ?", category.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the category"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted successfully"})
+}
+
+// nameAvailable rejects a category whose name is already used by a sibling.
+func nameAvailable(c *gin.Context, category database.Category) bool {
+	query := database.DB.Model(&database.Category{}).Where("LOWER(name) = ? AND id <> ?", strings.ToLower(category.Name), category.ID)
+	if category.ParentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *category.ParentID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting categories"})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category already exists"})
+		return false
+	}
+	return true
+}
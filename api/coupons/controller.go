@@ -0,0 +1,296 @@
+package coupons
+
+import (
+	"errors"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type CouponDetails struct {
+	Code         string         `json:"code" example:"SUMMER10"`
+	Description  string         `json:"description" example:"10% off everything"`
+	Type         string         `json:"type" example:"PERCENTAGE"`
+	Value        float64        `json:"value" example:"10"`
+	Amount       database.Money `json:"amount"`
+	MinSpend     database.Money `json:"min_spend"`
+	ExpiresAt    *time.Time     `json:"expires_at" example:"2030-01-01T00:00:00Z"`
+	UsageLimit   int            `json:"usage_limit" example:"100"`
+	PerUserLimit int            `json:"per_user_limit" example:"1"`
+	ProductIDs   []uint         `json:"product_ids" example:"1,2"`
+	CategoryIDs  []uint         `json:"category_ids" example:"3"`
+	Active       *bool          `json:"active" example:"true"`
+}
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+var couponSortColumns = map[string]string{
+	"created_at": "created_at",
+	"code":       "code",
+}
+
+// CreateCoupon godoc
+// @Summary Create a coupon
+// @Description Create a PERCENTAGE (value is the percent off), FIXED_AMOUNT (amount is the amount off, e.g. "20.00" or {"amount": "20.00", "currency": "USD"}) or FREE_SHIPPING coupon. Codes are case insensitive. Scope it to products or categories (including their subcategories) with product_ids and category_ids; usage_limit and per_user_limit of 0 mean unlimited (requires coupons:manage)
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param coupon body CouponDetails true "Coupon data"
+// @Success 201 {object} map[string]interface{} "Coupon created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error or code already exists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - coupons:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /coupons [post]
+func CreateCoupon(c *gin.Context) {
+	var couponDetails CouponDetails
+	if err := c.ShouldBindJSON(&couponDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	coupon := database.Coupon{Active: true}
+	if !applyCouponDetails(c, &coupon, couponDetails) {
+		return
+	}
+	if err := database.DB.Create(&coupon).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the coupon"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "coupon created successfully", "coupon": coupon})
+}
+
+// GetAllCoupons godoc
+// @Summary List coupons
+// @Description List coupons with offset (page) or cursor pagination (requires coupons:manage)
+// @Tags coupons
+// @Produce json
+// @Param page query int false "Page number (default 1), ignored when cursor is set"
+// @Param page_size query int false "Coupons per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort by created_at (default) or code"
+// @Param order query string false "asc or desc (default desc)"
+// @Param active query bool false "Only active or inactive coupons"
+// @Success 200 {object} map[string]interface{} "Coupons retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid filter or pagination parameter"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - coupons:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /coupons [get]
+func GetAllCoupons(c *gin.Context) {
+	params, err := utils.ParsePageParams(c, couponSortColumns, "created_at", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := database.DB.Model(&database.Coupon{})
+	switch c.Query("active") {
+	case "":
+	case "true":
+		query = query.Where("active = ?", true)
+	case "false":
+		query = query.Where("active = ?", false)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "active must be true or false"})
+		return
+	}
+	coupons, pagination, err := utils.Paginate(query, params, func(coupon database.Coupon) (interface{}, uint) {
+		if params.Sort == "code" {
+			return coupon.Code, coupon.ID
+		}
+		return coupon.CreatedAt, coupon.ID
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting coupons"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupons fetched successfully", "coupons": coupons, "pagination": pagination})
+}
+
+// GetCoupon godoc
+// @Summary Get a coupon
+// @Description Get a coupon by ID with how often it was used (requires coupons:manage)
+// @Tags coupons
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Success 200 {object} map[string]interface{} "Coupon retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - coupons:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Coupon not found"
+// @Security BearerAuth
+// @Router /coupons/{id} [get]
+func GetCoupon(c *gin.Context) {
+	var coupon database.Coupon
+	if err := database.DB.First(&coupon, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupon fetched successfully", "coupon": coupon})
+}
+
+// UpdateCoupon godoc
+// @Summary Update a coupon
+// @Description Replace a coupon's settings. Orders that already used it keep their discount (requires coupons:manage)
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Param coupon body CouponDetails true "Coupon data"
+// @Success 200 {object} map[string]interface{} "Coupon updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error or code already exists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - coupons:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Coupon not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /coupons/{id} [put]
+func UpdateCoupon(c *gin.Context) {
+	var coupon database.Coupon
+	if err := database.DB.First(&coupon, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+		return
+	}
+	var couponDetails CouponDetails
+	if err := c.ShouldBindJSON(&couponDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if !applyCouponDetails(c, &coupon, couponDetails) {
+		return
+	}
+	// used_count is only ever incremented by checkouts.
+	if err := database.DB.Omit("used_count").Save(&coupon).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the coupon"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupon updated successfully", "coupon": coupon})
+}
+
+// DeleteCoupon godoc
+// @Summary Delete a coupon
+// @Description Delete a coupon and remove it from every cart. Orders that already used it keep their discount (requires coupons:manage)
+// @Tags coupons
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Success 200 {object} map[string]interface{} "Coupon deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - coupons:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Coupon not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /coupons/{id} [delete]
+func DeleteCoupon(c *gin.Context) {
+	var coupon database.Coupon
+	if err := database.DB.First(&coupon, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.Cart{}).Where("coupon_id = ?", coupon.ID).Update("coupon_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&coupon).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the coupon"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupon deleted successfully"})
+}
+
+// applyCouponDetails validates the details and copies them onto the coupon.
+func applyCouponDetails(c *gin.Context, coupon *database.Coupon, couponDetails CouponDetails) bool {
+	code := utils.NormalizeCouponCode(couponDetails.Code)
+	couponDetails.Type = strings.ToUpper(strings.TrimSpace(couponDetails.Type))
+	if !couponCodePattern.MatchString(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code must be 3 to 32 letters, digits, dashes or underscores"})
+		return false
+	}
+	switch couponDetails.Type {
+	case utils.CouponTypePercentage:
+		if couponDetails.Value <= 0 || couponDetails.Value > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a percentage coupon needs a value between 0 and 100"})
+			return false
+		}
+		couponDetails.Amount = database.Money{}
+	case utils.CouponTypeFixedAmount:
+		if !couponDetails.Amount.IsPositive() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a fixed amount coupon needs a positive amount"})
+			return false
+		}
+		couponDetails.Value = 0
+	case utils.CouponTypeFreeShipping:
+		couponDetails.Value = 0
+		couponDetails.Amount = database.Money{}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be PERCENTAGE, FIXED_AMOUNT or FREE_SHIPPING"})
+		return false
+	}
+	if couponDetails.MinSpend.IsNegative() || couponDetails.UsageLimit < 0 || couponDetails.PerUserLimit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_spend, usage_limit and per_user_limit cannot be negative"})
+		return false
+	}
+	if !couponDetails.MinSpend.SameCurrency(couponDetails.Amount) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount and min_spend must be in the same currency"})
+		return false
+	}
+	var count int64
+	if err := database.DB.Model(&database.Coupon{}).Where("code = ? AND id <> ?", code, coupon.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting coupons"})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "coupon code already exists"})
+		return false
+	}
+	if !allExist(c, &database.Product{}, couponDetails.ProductIDs, "unknown product in request") ||
+		!allExist(c, &database.Category{}, couponDetails.CategoryIDs, "unknown category in request") {
+		return false
+	}
+	coupon.Code = code
+	coupon.Description = couponDetails.Description
+	coupon.Type = couponDetails.Type
+	coupon.Value = couponDetails.Value
+	coupon.Amount = couponDetails.Amount
+	coupon.MinSpend = couponDetails.MinSpend
+	coupon.ExpiresAt = couponDetails.ExpiresAt
+	coupon.UsageLimit = couponDetails.UsageLimit
+	coupon.PerUserLimit = couponDetails.PerUserLimit
+	coupon.ProductIDs = couponDetails.ProductIDs
+	coupon.CategoryIDs = couponDetails.CategoryIDs
+	if couponDetails.Active != nil {
+		coupon.Active = *couponDetails.Active
+	}
+	return true
+}
+
+// allExist checks that every id refers to a row of model's table.
+func allExist(c *gin.Context, model interface{}, ids []uint, message string) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	unique := map[uint]bool{}
+	for _, id := range ids {
+		unique[id] = true
+	}
+	var count int64
+	if err := database.DB.Model(model).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while checking coupon scope"})
+		return false
+	}
+	if int(count) != len(unique) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package currencies
+
+import (
+	"errors"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+type RateDetails struct {
+	Rate float64 `json:"rate" binding:"required" example:"0.92"`
+}
+
+// GetCurrencies godoc
+// @Summary List currencies
+// @Description List the base currency and the exchange rates of the other currencies prices can be shown and charged in. A rate is how many units of the currency one unit of the base currency buys
+// @Tags currencies
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Currencies retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /currencies [get]
+func GetCurrencies(c *gin.Context) {
+	rates := []database.ExchangeRate{}
+	if err := database.DB.Order("currency").Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting exchange rates"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "currencies fetched successfully", "base": database.DefaultCurrency(), "rates": rates})
+}
+
+// SetRate godoc
+// @Summary Set an exchange rate
+// @Description Create or replace the exchange rate of a currency against the base currency (requires currencies:manage)
+// @Tags currencies
+// @Accept json
+// @Produce json
+// @Param currency path string true "ISO 4217 currency code"
+// @Param rate body RateDetails true "Exchange rate"
+// @Success 200 {object} map[string]interface{} "Exchange rate saved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid currency or rate"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - currencies:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /currencies/rates/{currency} [put]
+func SetRate(c *gin.Context) {
+	var rateDetails RateDetails
+	if err := c.ShouldBindJSON(&rateDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	rate, err := utils.SetExchangeRate(database.DB, c.Param("currency"), rateDetails.Rate, utils.ExchangeRateSourceManual, c.GetUint("userId"))
+	if err != nil {
+		c.JSON(rateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "exchange rate saved successfully", "rate": rate})
+}
+
+// DeleteRate godoc
+// @Summary Delete an exchange rate
+// @Description Stop supporting a currency. Its price lists are kept but not used until it has a rate again (requires currencies:manage)
+// @Tags currencies
+// @Produce json
+// @Param currency path string true "ISO 4217 currency code"
+// @Success 200 {object} map[string]interface{} "Exchange rate deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - currencies:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Exchange rate not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /currencies/rates/{currency} [delete]
+func DeleteRate(c *gin.Context) {
+	var rate database.ExchangeRate
+	if err := database.DB.Where("currency = ?", strings.ToUpper(c.Param("currency"))).First(&rate).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exchange rate not found"})
+		return
+	}
+	if err := database.DB.Delete(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the exchange rate"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "exchange rate deleted successfully"})
+}
+
+// ImportRates godoc
+// @Summary Import exchange rates
+// @Description Load the exchange rates in EXCHANGE_RATES_FILE, a CSV file of currency,rate lines or a JSON object such as {"EUR": 0.92} or {"base": "USD", "rates": {"EUR": 0.92}}. Nothing is saved unless every rate is valid (requires currencies:manage)
+// @Tags currencies
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Exchange rates imported successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - missing or invalid rates file"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - currencies:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /currencies/rates/import [post]
+func ImportRates(c *gin.Context) {
+	rates, err := utils.ImportExchangeRates(c.GetUint("userId"))
+	if err != nil {
+		c.JSON(rateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "exchange rates imported successfully", "rates": rates})
+}
+
+func rateErrorStatus(err error) int {
+	for _, target := range []error{utils.ErrExchangeRatesFile, utils.ErrUnsupportedCurrency, utils.ErrInvalidExchangeRate, utils.ErrBaseCurrencyRate} {
+		if errors.Is(err, target) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,203 @@
+package inventory
+
+import (
+	"errors"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockAdjustment struct {
+	ProductID uint `json:"product_id" example:"1"`
+	VariantID uint `json:"variant_id" example:"0"`
+	// Quantity is signed: positive adds stock, negative removes it.
+	Quantity int    `json:"quantity" example:"-2"`
+	Type     string `json:"type" example:"ADJUSTMENT"`
+	Reason   string `json:"reason" example:"damaged in warehouse"`
+}
+
+var movementSortColumns = map[string]string{
+	"created_at": "created_at",
+}
+
+// AdjustStock godoc
+// @Summary Adjust stock
+// @Description Add or remove on-hand stock of a product or variant and record it in the stock ledger. type is RECEIPT for incoming goods or ADJUSTMENT (default) for corrections; stock cannot go below zero (requires inventory:manage)
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param adjustment body StockAdjustment true "Stock adjustment"
+// @Success 201 {object} map[string]interface{} "Stock adjusted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error, unknown variant or not enough stock"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - inventory:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /inventory/adjustments [post]
+func AdjustStock(c *gin.Context) {
+	var adjustment StockAdjustment
+	if err := c.ShouldBindJSON(&adjustment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	adjustment.Type = strings.ToUpper(strings.TrimSpace(adjustment.Type))
+	if adjustment.Type == "" {
+		adjustment.Type = database.StockMovementAdjustment
+	}
+	if adjustment.Type != database.StockMovementAdjustment && adjustment.Type != database.StockMovementReceipt {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be RECEIPT or ADJUSTMENT"})
+		return
+	}
+	if adjustment.ProductID == 0 || adjustment.Quantity == 0 || strings.TrimSpace(adjustment.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product_id, a non-zero quantity and reason are required"})
+		return
+	}
+	if adjustment.Type == database.StockMovementReceipt && adjustment.Quantity < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a receipt must add stock"})
+		return
+	}
+	var movement database.StockMovement
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := utils.ResolveStockItem(tx, adjustment.ProductID, adjustment.VariantID); err != nil {
+			return err
+		}
+		var err error
+		movement, err = utils.MoveStock(tx, utils.StockChange{
+			ProductID: adjustment.ProductID,
+			VariantID: adjustment.VariantID,
+			Type:      adjustment.Type,
+			Quantity:  adjustment.Quantity,
+			Reason:    strings.TrimSpace(adjustment.Reason),
+			Reference: "manual",
+			ActorID:   c.GetUint("userId"),
+		})
+		return err
+	})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, gin.H{"message": "stock adjusted successfully", "movement": movement})
+	case errors.Is(err, utils.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrVariantRequired), errors.Is(err, utils.ErrVariantNotFound), errors.Is(err, utils.ErrInsufficientStock):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetStockMovements godoc
+// @Summary List stock movements
+// @Description List the stock ledger, newest first, with offset (page) or cursor pagination. Every change of on-hand stock is a movement with a signed quantity; RESERVATION_RELEASE movements only record held stock becoming available again (requires inventory:manage)
+// @Tags inventory
+// @Produce json
+// @Param page query int false "Page number (default 1), ignored when cursor is set"
+// @Param page_size query int false "Movements per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param order query string false "asc or desc (default desc)"
+// @Param product_id query int false "Only movements of this product"
+// @Param variant_id query int false "Only movements of this variant"
+// @Param type query string false "Comma separated types, e.g. SALE,RETURN"
+// @Success 200 {object} map[string]interface{} "Stock movements retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid filter or pagination parameter"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - inventory:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /inventory/movements [get]
+func GetStockMovements(c *gin.Context) {
+	params, err := utils.ParsePageParams(c, movementSortColumns, "created_at", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := database.DB.Model(&database.StockMovement{})
+	for _, filter := range []string{"product_id", "variant_id"} {
+		value := c.Query(filter)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": filter + " must be a number"})
+			return
+		}
+		query = query.Where(filter+" = ?", id)
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		var types []string
+		for _, t := range strings.Split(movementType, ",") {
+			if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+		query = query.Where("type IN ?", types)
+	}
+	movements, pagination, err := utils.Paginate(query, params, func(movement database.StockMovement) (interface{}, uint) {
+		return movement.CreatedAt, movement.ID
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting stock movements"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "stock movements fetched successfully", "movements": movements, "pagination": pagination})
+}
+
+// GetStockAlerts godoc
+// @Summary List low-stock alerts
+// @Description List alerts raised when a product or variant fell to its low_stock_threshold (LOW_STOCK_THRESHOLD when unset). An alert is resolved once stock is back above the threshold (requires inventory:manage)
+// @Tags inventory
+// @Produce json
+// @Param status query string false "open (default) or all"
+// @Success 200 {object} map[string]interface{} "Stock alerts retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid status"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - inventory:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /inventory/alerts [get]
+func GetStockAlerts(c *gin.Context) {
+	query := database.DB.Order("created_at DESC, id DESC")
+	switch c.DefaultQuery("status", "open") {
+	case "open":
+		query = query.Where("resolved_at IS NULL")
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or all"})
+		return
+	}
+	alerts := []database.StockAlert{}
+	if err := query.Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting stock alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "stock alerts fetched successfully", "alerts": alerts})
+}
+
+// ReconcileStock godoc
+// @Summary Reconcile stock with the ledger
+// @Description Compare the stock_qty of every product and variant with the sum of its stock movements and list the ones that differ. The same check runs every STOCK_RECONCILE_INTERVAL in the background (requires inventory:manage)
+// @Tags inventory
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Stock reconciled successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - inventory:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /inventory/reconcile [post]
+func ReconcileStock(c *gin.Context) {
+	discrepancies, err := utils.ReconcileStock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "stock reconciled successfully", "balanced": len(discrepancies) == 0, "discrepancies": discrepancies})
+}
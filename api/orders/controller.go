@@ -1,25 +1,124 @@
 package orders
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/middleware"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
 	"github.com/gin-gonic/gin"
 )
 
+type PlaceOrderDetails struct {
+	// Zero ids stand for the user's default shipping and billing addresses.
+	ShippingAddressID uint `json:"shipping_address_id" example:"1"`
+	BillingAddressID  uint `json:"billing_address_id" example:"2"`
+	ShippingMethodID  uint `json:"shipping_method_id" example:"1"`
+}
 type DeliverDetails struct {
 	Order uint `json:"order" example:"1"`
 }
+type PayOrderDetails struct {
+	OrderID       uint   `json:"order_id" example:"1"`
+	PaymentMethod string `json:"payment_method" example:"virtual_card"`
+}
+type ConfirmPaymentDetails struct {
+	PaymentID uint `json:"payment_id" example:"1"`
+}
+type OrderSummary struct {
+	database.Order
+	ItemCount int            `json:"item_count" example:"3"`
+	Total     database.Money `json:"total"`
+}
+type OrderItemDetail struct {
+	database.OrderItem
+	LineTotal      database.Money `json:"line_total"`
+	ProductDeleted bool           `json:"product_deleted" example:"false"`
+}
+type OrderTotals struct {
+	Subtotal database.Money `json:"subtotal"`
+	Discount database.Money `json:"discount"`
+	Tax      database.Money `json:"tax"`
+	Shipping database.Money `json:"shipping"`
+	Total    database.Money `json:"total"`
+	Paid     database.Money `json:"paid"`
+	Refunded database.Money `json:"refunded"`
+	Balance  database.Money `json:"balance"`
+}
+type OrderDetail struct {
+	database.Order
+	Items      []OrderItemDetail        `json:"items"`
+	Payments   []database.Payment       `json:"payments"`
+	Refunds    []database.Refund        `json:"refunds"`
+	Shipments  []database.Shipment      `json:"shipments"`
+	Returns    []database.ReturnRequest `json:"returns"`
+	Totals     OrderTotals              `json:"totals"`
+	TaxSummary []utils.TaxSummaryLine   `json:"tax_summary"`
+}
+type InvoiceLine struct {
+	Description string         `json:"description" example:"T-Shirt (color: red, size: M)"`
+	SKU         string         `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Quantity    int            `json:"quantity" example:"2"`
+	UnitPrice   database.Money `json:"unit_price"`
+	Discount    database.Money `json:"discount"`
+	TaxRate     float64        `json:"tax_rate" example:"7.25"`
+	Tax         database.Money `json:"tax"`
+	Total       database.Money `json:"total"`
+}
+type InvoiceParty struct {
+	Name    string                  `json:"name" example:"John Doe"`
+	Email   string                  `json:"email,omitempty" example:"john@example.com"`
+	Address *database.PostalAddress `json:"address,omitempty"`
+}
+type Invoice struct {
+	Number       string                 `json:"number" example:"INV-000001"`
+	IssuedAt     time.Time              `json:"issued_at"`
+	OrderID      uint                   `json:"order_id" example:"1"`
+	Status       string                 `json:"status" example:"PAID"`
+	BillTo       InvoiceParty           `json:"bill_to"`
+	ShipTo       *InvoiceParty          `json:"ship_to,omitempty"`
+	TaxInclusive bool                   `json:"tax_inclusive" example:"false"`
+	TaxCountry   string                 `json:"tax_country" example:"US"`
+	TaxRegion    string                 `json:"tax_region" example:"CA"`
+	Lines        []InvoiceLine          `json:"lines"`
+	TaxSummary   []utils.TaxSummaryLine `json:"tax_summary"`
+	Totals       OrderTotals            `json:"totals"`
+}
+type CancelDetails struct {
+	Reason string `json:"reason" example:"ordered the wrong size"`
+}
+type RefundDetails struct {
+	Order  uint   `json:"order" example:"1"`
+	Reason string `json:"reason" example:"customer request"`
+}
+type PartialRefundDetails struct {
+	Order  uint               `json:"order" example:"1"`
+	Reason string             `json:"reason" example:"one item arrived damaged"`
+	Items  []utils.RefundLine `json:"items"`
+	Amount database.Money     `json:"amount"`
+}
 
 // PlaceOrder godoc
 // @Summary Place a new order
-// @Description Place an order using items from the user's cart
+// @Description Place an order using items from the user's cart. A coupon applied to the cart is checked again and its discount is recorded on the order. The order ships and bills to addresses from the user's address book, the default ones when no id is given (billing falls back to the shipping address), and keeps a copy of them. Once shipping methods are set up one must be chosen with shipping_method_id; it is priced from its rate table on the parcel's weight (or volumetric weight) or on the discounted goods value, and a free shipping coupon waives it. Each item is taxed at the rate of its product's tax class in the shipping address's country and region; with TAX_PRICES_INCLUDE_TAX the tax is part of the prices, otherwise it is added to them
 // @Tags orders
+// @Accept json
 // @Produce json
+// @Param order body PlaceOrderDetails false "Shipping and billing address ids and shipping method"
+// @Param X-Currency header string false "Currency to charge the order in (default the base currency), also accepted as the currency query parameter"
+// @Param Idempotency-Key header string false "Unique key for the request; a retry with the same key and body gets the first response back instead of running again"
 // @Success 200 {object} map[string]interface{} "Order placed successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - no shipping address or method, the method cannot ship the order, not enough stock or the coupon no longer applies"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
-// @Failure 404 {object} map[string]interface{} "User, cart, or cart items not found"
+// @Failure 404 {object} map[string]interface{} "User, address, shipping method, cart, or cart items not found"
+// @Failure 409 {object} map[string]interface{} "A request with the same Idempotency-Key is still being processed"
+// @Failure 422 {object} map[string]interface{} "The Idempotency-Key was used for a different request"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /orders/place-order [post]
@@ -29,12 +128,34 @@ func PlaceOrder(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
 		return
 	}
+	var orderDetails PlaceOrderDetails
+	if err := c.ShouldBindJSON(&orderDetails); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
 	var user database.User
 	var cart database.Cart
 	if err := database.DB.First(&user, userId).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
+	shippingAddress, billingAddress, err := utils.ResolveOrderAddresses(database.DB, user.ID, orderDetails.ShippingAddressID, orderDetails.BillingAddressID)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrShippingAddressUnset):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, utils.ErrAddressNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	taxZone, err := utils.NewTaxZone(shippingAddress.Country, shippingAddress.Region)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err := database.DB.First(&cart, user.Cart).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "cart not found"})
 		return
@@ -48,6 +169,39 @@ func PlaceOrder(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No cart items found"})
 		return
 	}
+	productIds := make([]uint, 0, len(cartItems))
+	for _, cartItem := range cartItems {
+		productIds = append(productIds, cartItem.ProductId)
+	}
+	priceList, err := utils.LoadPriceList(database.DB, middleware.RequestCurrency(c), productIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taxTable, err := utils.LoadTaxTable(database.DB, taxZone)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var shippingMethod *database.ShippingMethod
+	if orderDetails.ShippingMethodID != 0 {
+		method, err := utils.LoadShippingMethod(database.DB, orderDetails.ShippingMethodID)
+		if err != nil {
+			if errors.Is(err, utils.ErrShippingMethodNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		shippingMethod = &method
+	} else if configured, err := utils.ShippingMethodsConfigured(database.DB); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if configured {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.ErrShippingMethodRequired.Error()})
+		return
+	}
 
 	tx := database.DB.Begin()
 	if tx.Error != nil {
@@ -56,49 +210,163 @@ func PlaceOrder(c *gin.Context) {
 	}
 
 	order := database.Order{
-		Status: "PENDING",
-		UserId: user.ID,
-		Cart:   cart.ID,
+		Status:       utils.OrderStatusPending,
+		UserId:       user.ID,
+		Cart:         cart.ID,
+		TaxInclusive: utils.TaxInclusive(),
+		TaxCountry:   taxZone.Country,
+		TaxRegion:    taxZone.Region,
+		// Copies, so editing the address book never rewrites the order.
+		ShippingAddressID: shippingAddress.ID,
+		ShippingAddress:   shippingAddress.PostalAddress,
+		BillingAddressID:  billingAddress.ID,
+		BillingAddress:    billingAddress.PostalAddress,
 	}
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving order"})
 		return
 	}
+	if err := utils.RecordOrderStatus(tx, order.ID, "", order.Status, user.ID, "order placed"); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+	orderItems := make([]database.OrderItem, 0, len(cartItems))
+	products := make([]database.Product, 0, len(cartItems))
+	lines := make([]utils.CouponLine, 0, len(cartItems))
 	for _, cartItem := range cartItems {
-		var product database.Product
-		if err := tx.First(&product, cartItem.ProductId).Error; err != nil {
+		stockItem, err := utils.ResolveStockItem(tx, cartItem.ProductId, cartItem.VariantId)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusNotFound, gin.H{"error": "product not found for cart item"})
+			if errors.Is(err, utils.ErrProductNotFound) || errors.Is(err, utils.ErrVariantNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "product not found for cart item"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if product.StockQty < cartItem.Quantity {
+		// Locks the stock row and respects other carts' holds, so
+		// concurrent checkouts cannot oversell.
+		if err := utils.TakeStock(tx, cartItem, order.ID, user.ID); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "not enough stock for product: " + product.Name})
+			if errors.Is(err, utils.ErrInsufficientStock) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "not enough stock for product: " + stockItem.Label()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		// Create order item
 		orderItem := database.OrderItem{
-			OrderId:   order.ID,
-			ProductId: product.ID,
-			Quantity:  cartItem.Quantity,
-			Price:     product.Price,
+			OrderId:            order.ID,
+			ProductId:          stockItem.Product.ID,
+			VariantId:          stockItem.VariantID(),
+			ProductName:        stockItem.Product.Name,
+			ProductDescription: stockItem.Product.Description,
+			Quantity:           cartItem.Quantity,
+			Price:              priceList.UnitPrice(stockItem),
+			Discount:           database.NewMoney(0, priceList.Currency),
 		}
-		if err := tx.Create(&orderItem).Error; err != nil {
+		if stockItem.Variant != nil {
+			orderItem.SKU = stockItem.Variant.SKU
+			orderItem.VariantAttributes = stockItem.Variant.Attributes
+		}
+		orderItems = append(orderItems, orderItem)
+		products = append(products, stockItem.Product)
+		lines = append(lines, utils.CouponLine{ProductID: orderItem.ProductId, Quantity: orderItem.Quantity, UnitPrice: orderItem.Price})
+	}
+
+	// The cart's coupon is checked again against the order's prices and its
+	// use recorded, with the discount shared out over the items it covers.
+	freeShipping := false
+	if cart.CouponID != nil {
+		discount, err := utils.RedeemCoupon(tx, *cart.CouponID, user.ID, order.ID, lines)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create order item"})
+			if utils.IsCouponRejection(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "the cart's coupon can no longer be applied, remove it to continue: " + err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		// Decrement product stock
-		product.StockQty -= cartItem.Quantity;
-		if err := tx.Save(&product).Error; err != nil {
+		for i := range orderItems {
+			orderItems[i].Discount = discount.LineAmounts[i]
+		}
+		freeShipping = discount.FreeShipping
+		orderDiscount := database.OrderDiscount{
+			OrderID:     order.ID,
+			CouponID:    discount.Coupon.ID,
+			Code:        discount.Coupon.Code,
+			Type:        discount.Coupon.Type,
+			Description: discount.Coupon.Description,
+			Amount:      discount.Amount,
+		}
+		if err := tx.Create(&orderDiscount).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save order discount"})
+			return
+		}
+		order.Discounts = []database.OrderDiscount{orderDiscount}
+		if err := tx.Model(&cart).Update("coupon_id", nil).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update product stock"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clear the cart's coupon"})
 			return
 		}
 	}
+	// Tax is charged on what is paid for each line, after its discount.
+	for i := range orderItems {
+		taxTable.ApplyTax(&orderItems[i], products[i], order.TaxInclusive)
+	}
+	// Shipping is priced on the parcel's weight or on what the goods cost
+	// after discounts, in the base currency the rate tables are in.
+	if shippingMethod != nil {
+		parcel := utils.Parcel{}
+		value := database.NewMoney(0, priceList.Currency)
+		for i := range orderItems {
+			parcel.Add(products[i], orderItems[i].Quantity)
+			value = value.Add(orderItems[i].Price.Mul(orderItems[i].Quantity)).Sub(orderItems[i].Discount)
+		}
+		parcel.Value = priceList.ToBase(value)
+		price, err := utils.QuoteShipping(*shippingMethod, shippingAddress.Country, parcel)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		order.ShippingMethodID = shippingMethod.ID
+		order.ShippingMethod = shippingMethod.Name
+		if !freeShipping {
+			order.ShippingTotal = priceList.FromBase(price)
+		}
+	}
+	for i := range orderItems {
+		if err := tx.Create(&orderItems[i]).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create order item"})
+			return
+		}
+	}
+	// Totals are fixed now so later price changes never alter what is charged.
+	utils.CalculateOrderTotals(&order, orderItems, order.Discounts)
+	order.ExchangeRate = priceList.Rate()
+	order.BaseGrandTotal = priceList.ToBase(order.GrandTotal)
+	if err := tx.Model(&order).Select("subtotal_amount", "subtotal_currency", "discount_total_amount", "discount_total_currency",
+		"tax_total_amount", "tax_total_currency", "shipping_total_amount", "shipping_total_currency", "grand_total_amount", "grand_total_currency",
+		"exchange_rate", "base_grand_total_amount", "base_grand_total_currency", "shipping_method_id", "shipping_method").Updates(&order).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save order totals"})
+		return
+	}
 
+	// The stock is taken now, so the cart's holds are no longer needed.
+	if err := utils.ReleaseCartReservations(tx, cart.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release stock reservations"})
+		return
+	}
 	// Clear the cart (delete all cart items)
 	if err := tx.Where("cart_id = ?", cart.ID).Delete(&database.CartItem{}).Error; err != nil {
 		tx.Rollback()
@@ -112,20 +380,22 @@ func PlaceOrder(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order placed successfully", "order": order})
+	c.JSON(http.StatusOK, gin.H{"message": "Order placed successfully", "order": order, "tax_summary": utils.SummarizeTax(orderItems, order.TaxInclusive)})
 }
 
 // Deliver godoc
 // @Summary Deliver an order
-// @Description Mark an order as delivered (admin only)
+// @Description Mark every shipment of an order that is still on its way as delivered, which delivers a fully shipped order (requires orders:manage)
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order body DeliverDetails true "Order delivery details"
 // @Success 200 {object} map[string]interface{} "Order delivered successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
-// @Failure 401 {object} map[string]interface{} "Unauthorized - admin access required"
-// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - orders:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order has nothing on its way to deliver"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /orders/deliver [put]
@@ -135,48 +405,381 @@ func Deliver(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
 		return
 	}
-	var user database.User
-	if err := database.DB.First(&user, userId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	var deliverDetails DeliverDetails
+	if err := c.BindJSON(&deliverDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error while binding the request body"})
 		return
 	}
-	if user.Role == "user" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorised to perform this action"})
+	if err := utils.DeliverOrder(deliverDetails.Order, userId.(uint)); err != nil {
+		respondShipmentError(c, err)
 		return
 	}
-	var deliverDetails DeliverDetails
-	if err := c.BindJSON(&deliverDetails); err != nil {
+	c.JSON(http.StatusOK, gin.H{"message": "Order delivered successfully"})
+}
+
+// Ship godoc
+// @Summary Ship an order
+// @Description Ship everything of a paid order not yet shipped or refunded in one shipment, which moves the order to SHIPPED (requires orders:manage). Use POST /orders/{id}/shipments to split the order or record tracking
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body DeliverDetails true "Order to ship"
+// @Success 200 {object} map[string]interface{} "Order shipped successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - orders:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order cannot be shipped in its current status"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/ship [put]
+func Ship(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	var shipDetails DeliverDetails
+	if err := c.BindJSON(&shipDetails); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "error while binding the request body"})
 		return
 	}
+	shipment, err := utils.CreateShipment(shipDetails.Order, utils.ShipmentDetails{}, userId.(uint))
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
 	var order database.Order
-	if err := database.DB.First(&order, deliverDetails.Order).Error; err != nil {
+	if err := database.DB.First(&order, shipDetails.Order).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting the order"})
 		return
 	}
-	order.Status = "DELIVERED"
-	if err := database.DB.Save(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the order"})
+	c.JSON(http.StatusOK, gin.H{"message": "Order shipped successfully", "order": order, "shipment": shipment})
+}
+
+// CreateShipment godoc
+// @Summary Ship items of an order
+// @Description Record a shipment of some or all of a paid order's items with its carrier and tracking number (requires orders:manage). Without items it holds everything not yet shipped or refunded. Once every item is shipped the order moves to SHIPPED
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param shipment body utils.ShipmentDetails true "Carrier, tracking number and items to ship"
+// @Success 201 {object} map[string]interface{} "Shipment created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - unknown item or more than is left to ship"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - orders:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order is not paid or already fully shipped"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/shipments [post]
+func CreateShipment(c *gin.Context) {
+	orderId, err := parseOrderID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Order delivered successfully"})
+	var shipmentDetails utils.ShipmentDetails
+	if err := c.ShouldBindJSON(&shipmentDetails); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	shipment, err := utils.CreateShipment(orderId, shipmentDetails, c.GetUint("userId"))
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "shipment created successfully", "shipment": shipment})
 }
 
-// RejectOrder godoc
-// @Summary Reject an order
-// @Description Reject and delete an order (admin only)
+// GetOrderShipments godoc
+// @Summary List an order's shipments
+// @Description List the shipments of an order with their carrier, tracking number, items and delivery status (order owner or admin)
 // @Tags orders
-// @Accept json
 // @Produce json
-// @Param order body DeliverDetails true "Order rejection details"
-// @Success 200 {object} map[string]interface{} "Order rejected successfully"
+// @Param id path string true "Order ID"
+// @Success 200 {object} map[string]interface{} "Shipments retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/shipments [get]
+func GetOrderShipments(c *gin.Context) {
+	var order database.Order
+	if err := database.DB.First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if order.UserId != c.GetUint("userId") && !middleware.HasPermission(c, database.PermOrdersReadAll) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized to view this order"})
+		return
+	}
+	shipments, err := utils.OrderShipments(database.DB, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "shipments fetched successfully", "status": order.Status, "shipments": shipments})
+}
+
+// DeliverShipment godoc
+// @Summary Deliver a shipment
+// @Description Mark a shipment of an order as delivered (requires orders:manage). Once every shipment of a fully shipped order is delivered the order moves to DELIVERED
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param shipmentId path string true "Shipment ID"
+// @Success 200 {object} map[string]interface{} "Shipment delivered successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - orders:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Shipment not found"
+// @Failure 409 {object} map[string]interface{} "Shipment was already delivered"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/shipments/{shipmentId}/deliver [put]
+func DeliverShipment(c *gin.Context) {
+	orderId, err := parseOrderID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	shipmentId, err := strconv.ParseUint(c.Param("shipmentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shipment id"})
+		return
+	}
+	shipment, err := utils.DeliverShipment(orderId, uint(shipmentId), c.GetUint("userId"))
+	if err != nil {
+		respondShipmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "shipment delivered successfully", "shipment": shipment})
+}
+
+func parseOrderID(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid order id")
+	}
+	return uint(id), nil
+}
+
+func respondShipmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrOrderNotFound), errors.Is(err, utils.ErrShipmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrInvalidShipmentItem), errors.Is(err, utils.ErrShipmentTrackingUnset):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrOrderNotShippable), errors.Is(err, utils.ErrNothingToShip), errors.Is(err, utils.ErrShipmentDelivered),
+		errors.Is(err, utils.ErrNoShipmentsToDeliver), isTransitionError(err):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetMyOrders godoc
+// @Summary List my orders
+// @Description List the authenticated user's orders with offset (page) or cursor pagination
+// @Tags orders
+// @Produce json
+// @Param page query int false "Page number (default 1), ignored when cursor is set"
+// @Param page_size query int false "Orders per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort by created_at (default) or status"
+// @Param order query string false "asc or desc (default desc)"
+// @Param status query string false "Comma separated statuses to include, e.g. PAID,SHIPPED"
+// @Success 200 {object} map[string]interface{} "Orders retrieved successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
-// @Failure 401 {object} map[string]interface{} "Unauthorized - admin access required"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/mine [get]
+func GetMyOrders(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	params, err := utils.ParsePageParams(c, orderSortColumns, "created_at", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := database.DB.Model(&database.Order{}).Where("user_id = ?", userId)
+	if status := c.Query("status"); status != "" {
+		var statuses []string
+		for _, s := range strings.Split(status, ",") {
+			if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+				statuses = append(statuses, s)
+			}
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	orders, pagination, err := utils.Paginate(query, params, func(order database.Order) (interface{}, uint) {
+		if params.Sort == "status" {
+			return order.Status, order.ID
+		}
+		return order.CreatedAt, order.ID
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting orders"})
+		return
+	}
+	summaries := make([]OrderSummary, 0, len(orders))
+	if len(orders) > 0 {
+		orderIds := make([]uint, 0, len(orders))
+		for _, order := range orders {
+			orderIds = append(orderIds, order.ID)
+		}
+		var items []database.OrderItem
+		if err := database.DB.Where("order_id IN ?", orderIds).Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting order items"})
+			return
+		}
+		counts := map[uint]int{}
+		for _, item := range items {
+			counts[item.OrderId] += item.Quantity
+		}
+		for _, order := range orders {
+			summaries = append(summaries, OrderSummary{Order: order, ItemCount: counts[order.ID], Total: order.GrandTotal})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "orders fetched successfully", "orders": summaries, "pagination": pagination})
+}
+
+var orderSortColumns = map[string]string{
+	"created_at": "orders.created_at",
+	"status":     "orders.status",
+}
+
+// GetOrder godoc
+// @Summary Get an order
+// @Description Get an order with its items, discounts, payments, refunds, shipments, returns and totals (order owner or admin)
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} OrderDetail "Order retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 404 {object} map[string]interface{} "User or order not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
-// @Router /orders/reject [delete]
-func RejectOrder(c *gin.Context) {
+// @Router /orders/{id} [get]
+func GetOrder(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	var user database.User
+	if err := database.DB.First(&user, userId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	var order database.Order
+	if err := database.DB.First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if order.UserId != user.ID && !middleware.HasPermission(c, database.PermOrdersReadAll) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized to view this order"})
+		return
+	}
+	detail, err := loadOrderDetail(order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "order fetched successfully", "order": detail})
+}
+
+func loadOrderDetail(order database.Order) (OrderDetail, error) {
+	detail := OrderDetail{Order: order, Items: []OrderItemDetail{}}
+	var items []database.OrderItem
+	if err := database.DB.Where("order_id = ?", order.ID).Order("id asc").Find(&items).Error; err != nil {
+		return detail, errors.New("error while getting order items")
+	}
+	productIds := make([]uint, 0, len(items))
+	for _, item := range items {
+		productIds = append(productIds, item.ProductId)
+	}
+	var products []database.Product
+	if len(productIds) > 0 {
+		if err := database.DB.Where("id IN ?", productIds).Find(&products).Error; err != nil {
+			return detail, errors.New("error while getting order products")
+		}
+	}
+	liveProducts := map[uint]database.Product{}
+	for _, product := range products {
+		liveProducts[product.ID] = product
+	}
+	for _, item := range items {
+		product, found := liveProducts[item.ProductId]
+		// Orders placed before snapshots were stored fall back to the live product.
+		if item.ProductName == "" && found {
+			item.ProductName = product.Name
+			item.ProductDescription = product.Description
+		}
+		detail.Items = append(detail.Items, OrderItemDetail{OrderItem: item, LineTotal: item.Price.Mul(item.Quantity), ProductDeleted: !found})
+	}
+	if err := database.DB.Where("order_id = ?", order.ID).Order("id asc").Find(&detail.Discounts).Error; err != nil {
+		return detail, errors.New("error while getting order discounts")
+	}
+	detail.Totals.Subtotal = order.Subtotal
+	detail.Totals.Discount = order.DiscountTotal
+	detail.Totals.Tax = order.TaxTotal
+	detail.Totals.Shipping = order.ShippingTotal
+	detail.Totals.Total = order.GrandTotal
+	detail.Totals.Paid = database.NewMoney(0, order.GrandTotal.Currency)
+	detail.Totals.Refunded = detail.Totals.Paid
+	if err := database.DB.Where("order_id = ?", order.ID).Order("id asc").Find(&detail.Payments).Error; err != nil {
+		return detail, errors.New("error while getting order payments")
+	}
+	if err := database.DB.Preload("Items").Where("order_id = ?", order.ID).Order("id asc").Find(&detail.Refunds).Error; err != nil {
+		return detail, errors.New("error while getting order refunds")
+	}
+	shipments, err := utils.OrderShipments(database.DB, order.ID)
+	if err != nil {
+		return detail, errors.New("error while getting order shipments")
+	}
+	detail.Shipments = shipments
+	if err := database.DB.Preload("Items").Where("order_id = ?", order.ID).Order("id asc").Find(&detail.Returns).Error; err != nil {
+		return detail, errors.New("error while getting order returns")
+	}
+	for _, payment := range detail.Payments {
+		if payment.Status == utils.PaymentStatusPaid {
+			detail.Totals.Paid = detail.Totals.Paid.Add(payment.Amount)
+			detail.Totals.Refunded = detail.Totals.Refunded.Add(payment.RefundedAmount)
+		}
+	}
+	detail.Totals.Balance = detail.Totals.Paid.Sub(detail.Totals.Refunded)
+	orderItems := make([]database.OrderItem, 0, len(detail.Items))
+	for _, item := range detail.Items {
+		orderItems = append(orderItems, item.OrderItem)
+	}
+	detail.TaxSummary = utils.SummarizeTax(orderItems, order.TaxInclusive)
+	return detail, nil
+}
+
+// GetOrderInvoice godoc
+// @Summary Get an order's invoice
+// @Description Get the invoice of an order: its lines with their discount and tax, a summary of the tax charged at each rate, and its totals (order owner or admin)
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} Invoice "Invoice retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User or order not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/invoice [get]
+func GetOrderInvoice(c *gin.Context) {
 	userId, exists := c.Get("userId")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
@@ -187,10 +790,142 @@ func RejectOrder(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
-	if user.Role == "user" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorised to perform this action"})
+	var order database.Order
+	if err := database.DB.First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if order.UserId != user.ID && !middleware.HasPermission(c, database.PermOrdersReadAll) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized to view this order"})
 		return
 	}
+	detail, err := loadOrderDetail(order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	customer := user
+	if order.UserId != user.ID {
+		if err := database.DB.First(&customer, order.UserId).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting the customer"})
+			return
+		}
+	}
+	invoice := Invoice{
+		Number:       fmt.Sprintf("INV-%06d", order.ID),
+		IssuedAt:     order.CreatedAt,
+		OrderID:      order.ID,
+		Status:       order.Status,
+		BillTo:       InvoiceParty{Name: customer.Name, Email: customer.Email},
+		TaxInclusive: order.TaxInclusive,
+		TaxCountry:   order.TaxCountry,
+		TaxRegion:    order.TaxRegion,
+		Lines:        []InvoiceLine{},
+		TaxSummary:   detail.TaxSummary,
+		Totals:       detail.Totals,
+	}
+	// Orders placed before addresses were stored have none.
+	if order.BillingAddress.Country != "" {
+		billingAddress := order.BillingAddress
+		invoice.BillTo.Name = billingAddress.Name
+		invoice.BillTo.Address = &billingAddress
+	}
+	if order.ShippingAddress.Country != "" {
+		shippingAddress := order.ShippingAddress
+		invoice.ShipTo = &InvoiceParty{Name: shippingAddress.Name, Address: &shippingAddress}
+	}
+	for _, item := range detail.Items {
+		line := InvoiceLine{
+			Description: invoiceDescription(item.OrderItem),
+			SKU:         item.SKU,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Price,
+			Discount:    item.Discount,
+			TaxRate:     item.TaxRate,
+			Tax:         item.Tax,
+			Total:       item.Price.Mul(item.Quantity).Sub(item.Discount),
+		}
+		if !order.TaxInclusive {
+			line.Total = line.Total.Add(item.Tax)
+		}
+		invoice.Lines = append(invoice.Lines, line)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "invoice fetched successfully", "invoice": invoice})
+}
+
+// invoiceDescription names an order item with its variant's attributes.
+func invoiceDescription(item database.OrderItem) string {
+	if len(item.VariantAttributes) == 0 {
+		return item.ProductName
+	}
+	return item.ProductName + " (" + utils.FormatAttributes(item.VariantAttributes) + ")"
+}
+
+// GetOrderHistory godoc
+// @Summary Get order status history
+// @Description List every status change of an order, oldest first (order owner or admin)
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} map[string]interface{} "Order history retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "User or order not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/history [get]
+func GetOrderHistory(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	var user database.User
+	if err := database.DB.First(&user, userId).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	var order database.Order
+	if err := database.DB.First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if order.UserId != user.ID && !middleware.HasPermission(c, database.PermOrdersReadAll) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized to view this order"})
+		return
+	}
+	var history []database.OrderStatusHistory
+	if err := database.DB.Where("order_id = ?", order.ID).Order("created_at asc, id asc").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting the order history"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "order history fetched successfully", "status": order.Status, "history": history})
+}
+
+func respondTransitionError(c *gin.Context, err error) {
+	if isTransitionError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// RejectOrder godoc
+// @Summary Reject an order
+// @Description Cancel an unpaid order and put its items back in stock. The order is kept as CANCELLED (requires orders:manage)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body DeliverDetails true "Order rejection details"
+// @Success 200 {object} map[string]interface{} "Order rejected successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - orders:manage permission required"
+// @Failure 404 {object} map[string]interface{} "User or order not found"
+// @Failure 409 {object} map[string]interface{} "Order has been paid and must be refunded instead"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/reject [delete]
+func RejectOrder(c *gin.Context) {
 	var DeleteDetails DeliverDetails
 	if err := c.BindJSON(&DeleteDetails); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "error while bindind data"})
@@ -201,25 +936,194 @@ func RejectOrder(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
 		return
 	}
-	if err := database.DB.Delete(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the order"})
+	if !utils.CanTransitionOrder(order.Status, utils.OrderStatusCancelled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "order cannot be rejected in status " + order.Status})
+		return
+	}
+	if _, err := utils.CapturedPayment(database.DB, order.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "order has been paid, refund it instead of rejecting it"})
+		return
+	}
+	if _, err := utils.CancelOrder(order.ID, c.GetUint("userId"), "rejected"); err != nil {
+		respondCancelError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "order rejected successfully"})
 }
 
+// CancelOrder godoc
+// @Summary Cancel my order
+// @Description Cancel one of the authenticated user's orders while it awaits payment, or is paid but nothing of it has shipped. Its items go back in stock, a paid order is refunded in full through the payment gateway and coupon uses are given back. The order is kept as CANCELLED with the reason
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param cancellation body CancelDetails false "Why the order is cancelled"
+// @Success 200 {object} map[string]interface{} "Order cancelled successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order has shipped, is already closed or has a payment awaiting confirmation"
+// @Failure 502 {object} map[string]interface{} "Payment gateway rejected the refund"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/{id}/cancel [post]
+func CancelOrder(c *gin.Context) {
+	var cancelDetails CancelDetails
+	if err := c.ShouldBindJSON(&cancelDetails); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	var order database.Order
+	if err := database.DB.First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	userId := c.GetUint("userId")
+	if order.UserId != userId {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized to cancel this order"})
+		return
+	}
+	reason := strings.TrimSpace(cancelDetails.Reason)
+	if reason == "" {
+		reason = "cancelled by the customer"
+	}
+	refund, err := utils.CancelOrder(order.ID, userId, reason)
+	if err != nil {
+		respondCancelError(c, err)
+		return
+	}
+	if err := database.DB.First(&order, order.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting the order"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "order cancelled successfully", "order": order, "refund": refund})
+}
+
+func respondCancelError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrOrderNotCancellable), errors.Is(err, utils.ErrPaymentInProgress), isTransitionError(err):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrRefundFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// RefundOrder godoc
+// @Summary Refund an order in full
+// @Description Refund everything not yet refunded on a paid order and restock its items (requires orders:refund)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param refund body RefundDetails true "Refund details"
+// @Success 200 {object} map[string]interface{} "Order refunded successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - orders:refund permission required"
+// @Failure 404 {object} map[string]interface{} "User or order not found"
+// @Failure 409 {object} map[string]interface{} "Order cannot be refunded"
+// @Failure 502 {object} map[string]interface{} "Payment gateway rejected the refund"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/refund [post]
+func RefundOrder(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	var refundDetails RefundDetails
+	if err := c.BindJSON(&refundDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error while binding the request body"})
+		return
+	}
+	if refundDetails.Order == 0 || refundDetails.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order and reason are required"})
+		return
+	}
+	refund, err := utils.RefundOrderInFull(refundDetails.Order, userId.(uint), refundDetails.Reason)
+	respondRefund(c, refund, err)
+}
+
+// PartialRefund godoc
+// @Summary Partially refund an order
+// @Description Refund selected order items (restocking them) and/or a fixed amount, in the currency the order was paid in, on a paid order (requires orders:refund)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param refund body PartialRefundDetails true "Partial refund details"
+// @Success 200 {object} map[string]interface{} "Order refunded successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - orders:refund permission required"
+// @Failure 404 {object} map[string]interface{} "User or order not found"
+// @Failure 409 {object} map[string]interface{} "Order cannot be refunded"
+// @Failure 502 {object} map[string]interface{} "Payment gateway rejected the refund"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/refund/partial [post]
+func PartialRefund(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	var refundDetails PartialRefundDetails
+	if err := c.BindJSON(&refundDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error while binding the request body"})
+		return
+	}
+	if refundDetails.Order == 0 || refundDetails.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order and reason are required"})
+		return
+	}
+	if len(refundDetails.Items) == 0 && !refundDetails.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "items or an amount to refund are required"})
+		return
+	}
+	refund, err := utils.RefundOrderPartially(refundDetails.Order, userId.(uint), refundDetails.Reason, refundDetails.Items, refundDetails.Amount)
+	respondRefund(c, refund, err)
+}
+
+func respondRefund(c *gin.Context, refund database.Refund, err error) {
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "order refunded successfully", "refund": refund})
+	case errors.Is(err, utils.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrInvalidRefundItem), errors.Is(err, utils.ErrRefundExceedsAmount), errors.Is(err, utils.ErrRefundCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrOrderNotRefundable), errors.Is(err, utils.ErrNothingToRefund), isTransitionError(err):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrRefundFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "refund": refund})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // PayOrder godoc
 // @Summary Pay for an order
-// @Description Simulate virtual payment for an order
+// @Description Charge the grand total fixed when the order was placed through the configured payment gateway. The total is checked against the order's items first and the captured amount against the total. A 202 response means the gateway needs asynchronous confirmation, see /orders/pay/confirm.
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param payment body struct{OrderID uint `json:"order_id"`; PaymentMethod string `json:"payment_method"`} true "Payment details"
+// @Param payment body PayOrderDetails true "Payment details"
+// @Param Idempotency-Key header string false "Unique key for the request; a retry with the same key and body gets the first response back instead of running again"
 // @Success 200 {object} map[string]interface{} "Payment successful"
+// @Success 202 {object} map[string]interface{} "Payment awaiting confirmation"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 402 {object} map[string]interface{} "Payment declined"
 // @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order is not awaiting payment, its total does not match its items, or a request with the same Idempotency-Key is still being processed"
+// @Failure 422 {object} map[string]interface{} "The Idempotency-Key was used for a different request"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 504 {object} map[string]interface{} "Payment gateway timed out"
 // @Security BearerAuth
 // @Router /orders/pay [post]
 func PayOrder(c *gin.Context) {
@@ -228,10 +1132,7 @@ func PayOrder(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
 		return
 	}
-	var req struct {
-		OrderID       uint   `json:"order_id"`
-		PaymentMethod string `json:"payment_method"`
-	}
+	var req PayOrderDetails
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
@@ -241,14 +1142,80 @@ func PayOrder(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
 		return
 	}
-	if order.UserId != userId && userId != 1 {
+	if order.UserId != userId && !middleware.HasPermission(c, database.PermOrdersReadAll) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized to pay for this order"})
 		return
 	}
-	payment, err := utils.ProcessPayment(req.OrderID, req.PaymentMethod)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	payment, err := utils.ProcessPayment(req.OrderID, req.PaymentMethod, userId.(uint))
+	respondPayment(c, payment, err)
+}
+
+// ConfirmPayment godoc
+// @Summary Confirm a pending payment
+// @Description Ask the payment gateway for the outcome of a payment that required asynchronous confirmation
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param payment body ConfirmPaymentDetails true "Payment to confirm"
+// @Success 200 {object} map[string]interface{} "Payment successful"
+// @Success 202 {object} map[string]interface{} "Payment still awaiting confirmation"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 402 {object} map[string]interface{} "Payment declined"
+// @Failure 404 {object} map[string]interface{} "Payment not found"
+// @Failure 409 {object} map[string]interface{} "Payment is not awaiting confirmation"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /orders/pay/confirm [post]
+func ConfirmPayment(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	var req ConfirmPaymentDetails
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	var payment database.Payment
+	if err := database.DB.First(&payment, req.PaymentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Payment successful", "payment": payment})
+	var order database.Order
+	if err := database.DB.First(&order, payment.OrderID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if order.UserId != userId && !middleware.HasPermission(c, database.PermOrdersReadAll) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized to confirm this payment"})
+		return
+	}
+	payment, err := utils.ConfirmPayment(req.PaymentID, userId.(uint))
+	respondPayment(c, payment, err)
+}
+
+func respondPayment(c *gin.Context, payment database.Payment, err error) {
+	switch {
+	case err == nil && payment.Status == utils.PaymentStatusPending:
+		c.JSON(http.StatusAccepted, gin.H{"message": "Payment awaiting confirmation", "payment": payment})
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Payment successful", "payment": payment})
+	case errors.Is(err, utils.ErrOrderNotFound), errors.Is(err, utils.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrOrderNotPayable), errors.Is(err, utils.ErrPaymentNotPending), errors.Is(err, utils.ErrPaymentInProgress), errors.Is(err, utils.ErrOrderTotalMismatch), isTransitionError(err):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrPaymentDeclined):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error(), "payment": payment})
+	case errors.Is(err, utils.ErrGatewayTimeout):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error(), "payment": payment})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func isTransitionError(err error) bool {
+	var transitionErr *utils.InvalidTransitionError
+	return errors.As(err, &transitionErr) || errors.Is(err, utils.ErrOrderStatusChanged)
 }
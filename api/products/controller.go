@@ -1,57 +1,91 @@
 package products
 
 import (
+	"errors"
+	"fmt"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/middleware"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type ProductUpdate struct {
-	Name        string  `json:"name" example:"iPhone 15"`
-	Description string  `json:"description" example:"Latest iPhone model with advanced features"`
-	Price       float64 `json:"price" example:"999.99"`
-	StockQty    int     `json:"stock_qty" example:"50"`
+	Name        string         `json:"name" example:"iPhone 15"`
+	Description string         `json:"description" example:"Latest iPhone model with advanced features"`
+	Price       database.Money `json:"price"`
+	StockQty    int            `json:"stock_qty" example:"50"`
+	// LowStockThreshold of 0 falls back to LOW_STOCK_THRESHOLD.
+	LowStockThreshold *int `json:"low_stock_threshold" example:"10"`
+	// TaxClassID of 0 puts the product back in the standard tax class.
+	TaxClassID *uint `json:"tax_class_id" example:"2"`
+	// Weight and dimensions of one packed unit, which price its shipping.
+	WeightGrams *int `json:"weight_grams" example:"171"`
+	LengthMm    *int `json:"length_mm" example:"180"`
+	WidthMm     *int `json:"width_mm" example:"100"`
+	HeightMm    *int `json:"height_mm" example:"60"`
+}
+type VariantDetails struct {
+	SKU        string            `json:"sku" example:"TSHIRT-RED-M"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *database.Money   `json:"price"`
+	StockQty   *int              `json:"stock_qty" example:"10"`
+	// LowStockThreshold of 0 falls back to LOW_STOCK_THRESHOLD.
+	LowStockThreshold *int `json:"low_stock_threshold" example:"3"`
+}
+type PriceListEntry struct {
+	// VariantID of 0 sets the product's own price.
+	VariantID uint           `json:"variant_id" example:"0"`
+	Price     database.Money `json:"price"`
+}
+type ImageOrder struct {
+	ImageIDs []uint `json:"image_ids" example:"3,1,2"`
+}
+type ProductCategories struct {
+	CategoryIDs []uint `json:"category_ids" example:"1,2"`
 }
 
 // CreateProduct godoc
 // @Summary Create a new product
-// @Description Create a new product (admin only)
+// @Description Create a new product. price is a decimal such as "999.99" in DEFAULT_CURRENCY or an {"amount", "currency"} object in that currency; set prices in other currencies with price lists. Products without tax_class_id are in the standard tax class. weight_grams and length_mm, width_mm and height_mm of one packed unit price its shipping. Its initial stock is recorded as a RECEIPT in the stock ledger (requires products:write)
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param product body database.Product true "Product data"
 // @Success 201 {object} map[string]interface{} "Product created successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request - validation error or product already exists"
-// @Failure 401 {object} map[string]interface{} "Unauthorized - admin access required"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /products/create [post]
 func CreateProduct(c *gin.Context) {
 	var product database.Product
 	var eProduct database.Product
-	userId, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "you do not have access to this feature"})
+	if err := c.BindJSON(&product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	var user database.User
-	if err := database.DB.First(&user, userId).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user not found"})
+	if product.Description == "" || product.Name == "" || product.Price.IsZero() || product.StockQty == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "all products details are required"})
 		return
 	}
-	if user.Role == "user" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "you are not allowed for this action"})
+	if product.Price.IsNegative() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
 		return
 	}
-	if err := c.BindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if product.Description == "" || product.Name == "" || product.Price == 0 || product.StockQty == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "all products details are required"})
+	if product.Price.Currency != database.DefaultCurrency() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be in the base currency " + database.DefaultCurrency() + ", set other currencies with price lists"})
 		return
 	}
+	product.Categories = nil
+	product.Variants = nil
 	if err := database.DB.Where("name = ?", product.Name).First(&eProduct).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "product already exists"})
 		return
@@ -59,7 +93,34 @@ func CreateProduct(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting product"})
 		return
 	}
-	if err := database.DB.Create(&product).Error; err != nil {
+	if product.StockQty < 0 || product.LowStockThreshold < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stock_qty and low_stock_threshold cannot be negative"})
+		return
+	}
+	if !checkTaxClass(c, product.TaxClassID) {
+		return
+	}
+	if product.WeightGrams < 0 || product.LengthMm < 0 || product.WidthMm < 0 || product.HeightMm < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weight and dimensions cannot be negative"})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		initialStock := product.StockQty
+		product.StockQty = 0
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		movement, err := utils.MoveStock(tx, utils.StockChange{
+			ProductID: product.ID,
+			Type:      database.StockMovementReceipt,
+			Quantity:  initialStock,
+			Reason:    "initial stock",
+			ActorID:   c.GetUint("userId"),
+		})
+		product.StockQty = movement.StockAfter
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while saving the product!"})
 		return
 	}
@@ -68,27 +129,199 @@ func CreateProduct(c *gin.Context) {
 
 // GetAllProducts godoc
 // @Summary Get all products
-// @Description Retrieve all available products
+// @Description List products with offset (page) or cursor pagination, filtering and sorting
 // @Tags products
 // @Produce json
+// @Param page query int false "Page number (default 1), ignored when cursor is set"
+// @Param page_size query int false "Products per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort by price, name or created_at (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param q query string false "Name contains (case insensitive)"
+// @Param min_price query string false "Minimum price, in the request currency, e.g. 19.99"
+// @Param max_price query string false "Maximum price, in the request currency, e.g. 99.99"
+// @Param in_stock query bool false "Only products with stock, counting the stock of their variants"
+// @Param category query int false "Only products in this category or any of its subcategories"
+// @Param X-Currency header string false "Currency to show prices in (default the base currency), also accepted as the currency query parameter"
 // @Success 200 {object} map[string]interface{} "Products retrieved successfully"
-// @Failure 404 {object} map[string]interface{} "Products not found"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid filter or pagination parameter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products/all [get]
 func GetAllProducts(c *gin.Context) {
-	var products []database.Product
-	if err := database.DB.Find(&products).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "products not found"})
+	params, err := utils.ParsePageParams(c, productSortColumns, "created_at", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	priceList, err := utils.LoadPriceList(database.DB, middleware.RequestCurrency(c), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "products fetched successfully", "products": products})
+	query := pricedProducts(priceList)
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("LOWER(products.name) LIKE ?", "%"+strings.ToLower(q)+"%")
+	}
+	if value := c.Query("min_price"); value != "" {
+		minPrice, err := database.ParseMoney(value, priceList.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_price must be a decimal amount"})
+			return
+		}
+		query = query.Where("products.list_price >= ?", minPrice.Amount)
+	}
+	if value := c.Query("max_price"); value != "" {
+		maxPrice, err := database.ParseMoney(value, priceList.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_price must be a decimal amount"})
+			return
+		}
+		query = query.Where("products.list_price <= ?", maxPrice.Amount)
+	}
+	if value := c.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "in_stock must be true or false"})
+			return
+		}
+		if inStock {
+			query = query.Where("products.stock_qty > 0 OR EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id AND product_variants.stock_qty > 0)")
+		}
+	}
+	if value := c.Query("category"); value != "" {
+		categoryId, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category must be a category id"})
+			return
+		}
+		categoryIds, err := utils.CategoryDescendantIDs(uint(categoryId))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting categories"})
+			return
+		}
+		query = query.Where("products.id IN (SELECT product_id FROM product_categories WHERE category_id IN ?)", categoryIds)
+	}
+	products, pagination, err := utils.Paginate(query, params, productSortKey(params.Sort, priceList))
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting products"})
+		return
+	}
+	if err := utils.SetAvailability(products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := localizePrices(c, products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "products fetched successfully", "products": products, "pagination": pagination})
+}
+
+// pricedProducts queries products with their price in the list's currency
+// as list_price, so they are filtered and sorted on the prices shown.
+func pricedProducts(priceList utils.PriceList) *gorm.DB {
+	return database.DB.Model(&database.Product{}).
+		Table("(SELECT products.*, ? AS list_price FROM products) AS products", priceList.ProductPriceExpr())
+}
+
+var productSortColumns = map[string]string{
+	"price":      "products.list_price",
+	"name":       "products.name",
+	"created_at": "products.create_at",
+}
+
+func productSortKey(sort string, priceList utils.PriceList) func(database.Product) (interface{}, uint) {
+	return func(product database.Product) (interface{}, uint) {
+		switch sort {
+		case "price":
+			if priceList.Currency == database.DefaultCurrency() {
+				return product.Price.Amount, product.ID
+			}
+			var price int64
+			if err := pricedProducts(priceList).Where("products.id = ?", product.ID).Select("products.list_price").Scan(&price).Error; err != nil {
+				log.Printf("failed to load the list price of product %d: %v", product.ID, err)
+				return product.Price.Amount, product.ID
+			}
+			return price, product.ID
+		case "name":
+			return product.Name, product.ID
+		}
+		return product.CreateAt, product.ID
+	}
+}
+
+// SearchProducts godoc
+// @Summary Search products
+// @Description Full-text search over product names and descriptions, ranked by relevance. Words are matched as prefixes and matches are wrapped in <mark> tags in name_highlight and snippet, whose other text is HTML-escaped.
+// @Tags products
+// @Produce json
+// @Param q query string true "Search words"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Results per page (default 20, max 100)"
+// @Param order query string false "desc, the only order of ranked results"
+// @Param X-Currency header string false "Currency to show prices in (default the base currency), also accepted as the currency query parameter"
+// @Success 200 {object} map[string]interface{} "Search results"
+// @Failure 400 {object} map[string]interface{} "Bad request - missing query, invalid pagination or a cursor"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/search [get]
+func SearchProducts(c *gin.Context) {
+	params, err := utils.ParsePageParams(c, map[string]string{"relevance": "rank"}, "relevance", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Results are ranked, so they are paged by offset only.
+	if params.Cursor != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "search results are paged with page, cursor is not supported"})
+		return
+	}
+	if !params.Desc {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "search results can only be ordered desc"})
+		return
+	}
+	results, total, err := utils.SearchProducts(c.Query("q"), params.Page, params.PageSize)
+	if err != nil {
+		if errors.Is(err, utils.ErrEmptySearch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while searching products"})
+		return
+	}
+	pagination := utils.PageMeta{
+		Total:    total,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Sort:     params.Sort,
+		Order:    "desc",
+		HasMore:  int64(params.Page*params.PageSize) < total,
+	}
+	productIds := make([]uint, 0, len(results))
+	for _, result := range results {
+		productIds = append(productIds, result.ID)
+	}
+	priceList, err := utils.LoadPriceList(database.DB, middleware.RequestCurrency(c), productIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range results {
+		results[i].Price = priceList.ProductPrice(results[i].Product)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "products fetched successfully", "products": results, "pagination": pagination})
 }
 
 // GetOneProduct godoc
 // @Summary Get a specific product
-// @Description Retrieve a product by its ID
+// @Description Retrieve a product by its ID with its categories, variants and images. available_qty is the on-hand stock_qty minus what carts currently hold
 // @Tags products
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param X-Currency header string false "Currency to show prices in (default the base currency), also accepted as the currency query parameter"
 // @Success 200 {object} map[string]interface{} "Product retrieved successfully"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Router /products/{id} [get]
@@ -99,61 +332,69 @@ func GetOneProduct(c *gin.Context) {
 		return
 	}
 	var product database.Product
-	if err := database.DB.First(&product, productId).Error; err != nil {
+	err := database.DB.Preload("Categories").Preload("Variants").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		First(&product, productId).Error
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "product fetched successfully", "product": product})
+	withAvailability := []database.Product{product}
+	if err := utils.SetAvailability(withAvailability); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := localizePrices(c, withAvailability); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "product fetched successfully", "product": withAvailability[0]})
 }
 
 // DeleteProduct godoc
 // @Summary Delete a product
-// @Description Delete a product by ID (admin only)
+// @Description Delete a product with its variants and images by ID (requires products:write)
 // @Tags products
 // @Produce json
 // @Param id path string true "Product ID"
 // @Success 200 {object} map[string]interface{} "Product deleted successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
-// @Failure 401 {object} map[string]interface{} "Unauthorized - admin access required"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /products/delete/{id} [delete]
 func DeleteProduct(c *gin.Context) {
 	productId := c.Param("id")
-	userId, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
-		return
-	}
 	if productId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "product id not found"})
 		return
 	}
-	var user database.User
-	if err := database.DB.First(&user, userId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"Error": "User not found"})
-		return
-	}
-	if user.Role == "user" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authorized for this action"})
-		return
-	}
 	var product database.Product
 	if err := database.DB.First(&product, productId).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 		return
 	}
-	if err := database.DB.Delete(&product).Error; err != nil {
+	images, err := utils.ProductImages(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while deleting product"})
+		return
+	}
+	if err := database.DB.Select("Categories", "Variants", "Images", "Prices").Delete(&product).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while deleting product"})
 		return
 	}
+	if err := utils.DeleteImageFiles(images); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "product deleted but its image files could not be removed"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "product deleted successfully"})
 }
 
 // UpdateProduct godoc
 // @Summary Update a product
-// @Description Update product details by ID (admin only)
+// @Description Update product details by ID. A stock_qty change is recorded as an ADJUSTMENT in the stock ledger; tax_class_id 0 puts the product back in the standard tax class (requires products:write)
 // @Tags products
 // @Accept json
 // @Produce json
@@ -161,31 +402,18 @@ func DeleteProduct(c *gin.Context) {
 // @Param product body ProductUpdate true "Product update data"
 // @Success 200 {object} map[string]interface{} "Product updated successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
-// @Failure 401 {object} map[string]interface{} "Unauthorized - admin access required"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /products/update/{id} [put]
 func UpdateProduct(c *gin.Context) {
-	userId, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "login first to continue"})
-		return
-	}
 	productId := c.Param("id")
 	if productId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "product id not provided"})
 		return
 	}
-	var user database.User
-	if err := database.DB.First(&user, userId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-		return
-	}
-	if user.Role == "user" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "you are not authorized to perform this action"})
-		return
-	}
 	var product database.Product
 	if err := database.DB.First(&product, productId).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
@@ -196,21 +424,762 @@ func UpdateProduct(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if productUpdateDetails.Description != "" {
-		product.Description = productUpdateDetails.Description
+	if productUpdateDetails.StockQty < 0 || (productUpdateDetails.LowStockThreshold != nil && *productUpdateDetails.LowStockThreshold < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stock_qty and low_stock_threshold cannot be negative"})
+		return
 	}
-	if productUpdateDetails.Name != "" {
-		product.Name = productUpdateDetails.Name
+	if productUpdateDetails.Price.IsSet() && !productUpdateDetails.Price.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be positive"})
+		return
 	}
-	if productUpdateDetails.Price != 0 {
-		product.Price = productUpdateDetails.Price
+	if !productUpdateDetails.Price.SameCurrency(product.Price) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be in " + product.Price.Currency})
+		return
+	}
+	for _, size := range []*int{productUpdateDetails.WeightGrams, productUpdateDetails.LengthMm, productUpdateDetails.WidthMm, productUpdateDetails.HeightMm} {
+		if size != nil && *size < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weight and dimensions cannot be negative"})
+			return
+		}
 	}
-	if productUpdateDetails.StockQty != 0 {
-		product.StockQty = productUpdateDetails.StockQty
+	clearTaxClass := productUpdateDetails.TaxClassID != nil && *productUpdateDetails.TaxClassID == 0
+	if !clearTaxClass && !checkTaxClass(c, productUpdateDetails.TaxClassID) {
+		return
 	}
-	if err := database.DB.Save(&product).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the row so the stock delta is taken against the current stock.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, product.ID).Error; err != nil {
+			return err
+		}
+		if productUpdateDetails.Description != "" {
+			product.Description = productUpdateDetails.Description
+		}
+		if productUpdateDetails.Name != "" {
+			product.Name = productUpdateDetails.Name
+		}
+		if productUpdateDetails.Price.IsSet() {
+			product.Price = productUpdateDetails.Price
+		}
+		if productUpdateDetails.LowStockThreshold != nil {
+			product.LowStockThreshold = *productUpdateDetails.LowStockThreshold
+		}
+		if clearTaxClass {
+			product.TaxClassID = nil
+		} else if productUpdateDetails.TaxClassID != nil {
+			product.TaxClassID = productUpdateDetails.TaxClassID
+		}
+		if productUpdateDetails.WeightGrams != nil {
+			product.WeightGrams = *productUpdateDetails.WeightGrams
+		}
+		if productUpdateDetails.LengthMm != nil {
+			product.LengthMm = *productUpdateDetails.LengthMm
+		}
+		if productUpdateDetails.WidthMm != nil {
+			product.WidthMm = *productUpdateDetails.WidthMm
+		}
+		if productUpdateDetails.HeightMm != nil {
+			product.HeightMm = *productUpdateDetails.HeightMm
+		}
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		delta := productUpdateDetails.StockQty - product.StockQty
+		if productUpdateDetails.StockQty == 0 || delta == 0 {
+			return nil
+		}
+		movement, err := utils.MoveStock(tx, utils.StockChange{
+			ProductID: product.ID,
+			Type:      database.StockMovementAdjustment,
+			Quantity:  delta,
+			Reason:    "product update",
+			ActorID:   c.GetUint("userId"),
+		})
+		product.StockQty = movement.StockAfter
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while updating product"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "product updated successfully", "product": product})
-}
\ No newline at end of file
+}
+
+// SetProductCategories godoc
+// @Summary Set a product's categories
+// @Description Replace the categories a product belongs to (requires products:write)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param categories body ProductCategories true "Categories to assign"
+// @Success 200 {object} map[string]interface{} "Product categories updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - unknown category"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/categories [put]
+func SetProductCategories(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	var productCategories ProductCategories
+	if err := c.ShouldBindJSON(&productCategories); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	categories := []database.Category{}
+	if len(productCategories.CategoryIDs) > 0 {
+		if err := database.DB.Where("id IN ?", productCategories.CategoryIDs).Find(&categories).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting categories"})
+			return
+		}
+		unique := map[uint]bool{}
+		for _, id := range productCategories.CategoryIDs {
+			unique[id] = true
+		}
+		if len(categories) != len(unique) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown category in request"})
+			return
+		}
+	}
+	if err := database.DB.Model(&product).Association("Categories").Replace(categories); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating product categories"})
+		return
+	}
+	product.Categories = categories
+	c.JSON(http.StatusOK, gin.H{"message": "product categories updated successfully", "product": product})
+}
+
+// GetProductVariants godoc
+// @Summary Get a product's variants
+// @Description List the variants of a product with their SKU, attributes, price, on-hand stock and available stock (on hand minus what carts hold). A null price means the product's price applies.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param X-Currency header string false "Currency to show prices in (default the base currency), also accepted as the currency query parameter"
+// @Success 200 {object} map[string]interface{} "Variants retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/variants [get]
+func GetProductVariants(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	variants := []database.ProductVariant{}
+	if err := database.DB.Where("product_id = ?", product.ID).Order("id").Find(&variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting variants"})
+		return
+	}
+	if err := utils.SetVariantAvailability(variants); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	priceList, err := utils.LoadPriceList(database.DB, middleware.RequestCurrency(c), []uint{product.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	priceList.LocalizeVariants(variants)
+	c.JSON(http.StatusOK, gin.H{"message": "variants fetched successfully", "variants": variants})
+}
+
+// CreateProductVariant godoc
+// @Summary Create a product variant
+// @Description Add a variant such as a size or colour to a product. Once a product has variants, customers must pick one when adding it to their cart. Its initial stock is recorded as a RECEIPT in the stock ledger (requires products:write)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant body VariantDetails true "Variant data"
+// @Success 201 {object} map[string]interface{} "Variant created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error, duplicate SKU or attributes"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/variants [post]
+func CreateProductVariant(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	var variantDetails VariantDetails
+	if err := c.ShouldBindJSON(&variantDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if strings.TrimSpace(variantDetails.SKU) == "" || len(variantDetails.Attributes) == 0 || variantDetails.StockQty == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sku, attributes and stock_qty are required"})
+		return
+	}
+	variant := database.ProductVariant{ProductID: product.ID}
+	if !applyVariantDetails(c, &variant, variantDetails) {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		variant.StockQty = 0
+		if err := tx.Create(&variant).Error; err != nil {
+			return err
+		}
+		if *variantDetails.StockQty == 0 {
+			return nil
+		}
+		movement, err := utils.MoveStock(tx, utils.StockChange{
+			ProductID: product.ID,
+			VariantID: variant.ID,
+			Type:      database.StockMovementReceipt,
+			Quantity:  *variantDetails.StockQty,
+			Reason:    "initial stock",
+			ActorID:   c.GetUint("userId"),
+		})
+		variant.StockQty = movement.StockAfter
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the variant"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "variant created successfully", "variant": variant})
+}
+
+// UpdateProductVariant godoc
+// @Summary Update a product variant
+// @Description Change a variant's SKU, attributes, price, stock or low-stock threshold. A price of 0 removes the override so the product's price applies; a stock change is recorded as an ADJUSTMENT in the stock ledger (requires products:write)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Param variant body VariantDetails true "Variant update data"
+// @Success 200 {object} map[string]interface{} "Variant updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error, duplicate SKU or attributes"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Variant not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/variants/{variantId} [put]
+func UpdateProductVariant(c *gin.Context) {
+	var variant database.ProductVariant
+	if err := database.DB.Where("id = ? AND product_id = ?", c.Param("variantId"), c.Param("id")).First(&variant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "variant not found"})
+		return
+	}
+	var variantDetails VariantDetails
+	if err := c.ShouldBindJSON(&variantDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if !applyVariantDetails(c, &variant, variantDetails) {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var current database.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, variant.ID).Error; err != nil {
+			return err
+		}
+		target := variant.StockQty
+		variant.StockQty = current.StockQty
+		if err := tx.Save(&variant).Error; err != nil {
+			return err
+		}
+		if variantDetails.StockQty == nil || target == current.StockQty {
+			return nil
+		}
+		movement, err := utils.MoveStock(tx, utils.StockChange{
+			ProductID: variant.ProductID,
+			VariantID: variant.ID,
+			Type:      database.StockMovementAdjustment,
+			Quantity:  target - current.StockQty,
+			Reason:    "variant update",
+			ActorID:   c.GetUint("userId"),
+		})
+		variant.StockQty = movement.StockAfter
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the variant"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "variant updated successfully", "variant": variant})
+}
+
+// DeleteProductVariant godoc
+// @Summary Delete a product variant
+// @Description Delete a variant and remove it from every cart. Existing orders keep their SKU and attributes (requires products:write)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Success 200 {object} map[string]interface{} "Variant deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Variant not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/variants/{variantId} [delete]
+func DeleteProductVariant(c *gin.Context) {
+	var variant database.ProductVariant
+	if err := database.DB.Where("id = ? AND product_id = ?", c.Param("variantId"), c.Param("id")).First(&variant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "variant not found"})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&database.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&database.StockReservation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&database.ProductPrice{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&variant).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the variant"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "variant deleted successfully"})
+}
+
+// applyVariantDetails copies the non-empty fields onto the variant, checking
+// that its SKU and attribute combination are not used by another variant.
+func applyVariantDetails(c *gin.Context, variant *database.ProductVariant, variantDetails VariantDetails) bool {
+	if sku := strings.TrimSpace(variantDetails.SKU); sku != "" {
+		var count int64
+		if err := database.DB.Model(&database.ProductVariant{}).Where("sku = ? AND id <> ?", sku, variant.ID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting variants"})
+			return false
+		}
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sku is already used by another variant"})
+			return false
+		}
+		variant.SKU = sku
+	}
+	if len(variantDetails.Attributes) > 0 {
+		var siblings []database.ProductVariant
+		if err := database.DB.Where("product_id = ? AND id <> ?", variant.ProductID, variant.ID).Find(&siblings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting variants"})
+			return false
+		}
+		label := utils.FormatAttributes(variantDetails.Attributes)
+		for _, sibling := range siblings {
+			if utils.FormatAttributes(sibling.Attributes) == label {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "another variant already has these attributes"})
+				return false
+			}
+		}
+		variant.Attributes = variantDetails.Attributes
+	}
+	if variantDetails.Price != nil {
+		var currency string
+		if err := database.DB.Model(&database.Product{}).Where("id = ?", variant.ProductID).Select("price_currency").Scan(&currency).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting product"})
+			return false
+		}
+		switch {
+		case variantDetails.Price.IsNegative():
+			c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
+			return false
+		case variantDetails.Price.IsZero():
+			variant.Price = database.Money{}
+		case variantDetails.Price.Currency != currency:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "price must be in the product's currency " + currency})
+			return false
+		default:
+			variant.Price = *variantDetails.Price
+		}
+	}
+	if variantDetails.StockQty != nil {
+		if *variantDetails.StockQty < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stock_qty cannot be negative"})
+			return false
+		}
+		variant.StockQty = *variantDetails.StockQty
+	}
+	if variantDetails.LowStockThreshold != nil {
+		if *variantDetails.LowStockThreshold < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "low_stock_threshold cannot be negative"})
+			return false
+		}
+		variant.LowStockThreshold = *variantDetails.LowStockThreshold
+	}
+	return true
+}
+
+// GetProductPrices godoc
+// @Summary Get a product's price lists
+// @Description List the prices set for a product and its variants in currencies other than the base currency (DEFAULT_CURRENCY). Products without a list price in a currency are sold at their base price converted at the exchange rate (requires products:write)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} map[string]interface{} "Prices retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/prices [get]
+func GetProductPrices(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	prices := []database.ProductPrice{}
+	if err := database.DB.Where("product_id = ?", product.ID).Order("price_currency, variant_id").Find(&prices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting prices"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "prices fetched successfully", "base_price": product.Price, "prices": prices})
+}
+
+// SetProductPrice godoc
+// @Summary Set a list price
+// @Description Set the price of a product, or of one of its variants with variant_id, in a currency other than the base currency, replacing any price it had in that currency. The currency needs an exchange rate (requires products:write)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param price body PriceListEntry true "List price"
+// @Success 200 {object} map[string]interface{} "Price saved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid price, base or unsupported currency, or unknown variant"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/prices [put]
+func SetProductPrice(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	var entry PriceListEntry
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !entry.Price.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be positive"})
+		return
+	}
+	if entry.Price.Currency == database.DefaultCurrency() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.ErrPriceListBaseCurrency.Error()})
+		return
+	}
+	supported, err := utils.IsSupportedCurrency(database.DB, entry.Price.Currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !supported {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported currency " + entry.Price.Currency})
+		return
+	}
+	if entry.VariantID != 0 {
+		var count int64
+		if err := database.DB.Model(&database.ProductVariant{}).Where("id = ? AND product_id = ?", entry.VariantID, product.ID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting variants"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "variant not found for this product"})
+			return
+		}
+	}
+	price := database.ProductPrice{ProductID: product.ID, VariantID: entry.VariantID}
+	err = database.DB.Where("product_id = ? AND variant_id = ? AND price_currency = ?", product.ID, entry.VariantID, entry.Price.Currency).
+		FirstOrInit(&price).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting prices"})
+		return
+	}
+	price.Price = entry.Price
+	if err := database.DB.Save(&price).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the price"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "price saved successfully", "price": price})
+}
+
+// DeleteProductPrice godoc
+// @Summary Delete a list price
+// @Description Remove a list price so the base price converted at the exchange rate applies again (requires products:write)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param priceId path string true "Price ID"
+// @Success 200 {object} map[string]interface{} "Price deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Price not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/prices/{priceId} [delete]
+func DeleteProductPrice(c *gin.Context) {
+	var price database.ProductPrice
+	if err := database.DB.Where("id = ? AND product_id = ?", c.Param("priceId"), c.Param("id")).First(&price).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "price not found"})
+		return
+	}
+	if err := database.DB.Delete(&price).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the price"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "price deleted successfully"})
+}
+
+// checkTaxClass responds with an error when a tax class set on a product
+// does not exist.
+func checkTaxClass(c *gin.Context, classID *uint) bool {
+	if err := utils.CheckTaxClass(database.DB, classID); err != nil {
+		if errors.Is(err, utils.ErrTaxClassNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// localizePrices shows the products' prices in the request's currency.
+func localizePrices(c *gin.Context, products []database.Product) error {
+	productIds := make([]uint, 0, len(products))
+	for _, product := range products {
+		productIds = append(productIds, product.ID)
+	}
+	priceList, err := utils.LoadPriceList(database.DB, middleware.RequestCurrency(c), productIds)
+	if err != nil {
+		return err
+	}
+	priceList.Localize(products)
+	return nil
+}
+
+const maxImagesPerUpload = 10
+
+// UploadProductImages godoc
+// @Summary Upload product images
+// @Description Upload up to 10 JPEG, PNG or GIF images (MAX_IMAGE_SIZE_MB each, 5 MB by default) as multipart form files named images. The file type is detected from the content, small/medium/large thumbnails are generated, and the first image of a product becomes its primary image (requires products:write)
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param images formData file true "Image files"
+// @Success 201 {object} map[string]interface{} "Images uploaded successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - missing, unsupported or too large image"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/images [post]
+func UploadProductImages(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	maxSize := utils.MaxImageSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImagesPerUpload*maxSize+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "images must be sent as multipart/form-data within the size limit"})
+		return
+	}
+	files := form.File["images"]
+	if len(files) == 0 || len(files) > maxImagesPerUpload {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("send between 1 and %d files in the images field", maxImagesPerUpload)})
+		return
+	}
+	images := []database.ProductImage{}
+	for _, fileHeader := range files {
+		if fileHeader.Size > maxSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is larger than %d MB", fileHeader.Filename, maxSize>>20), "images": images})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read " + fileHeader.Filename, "images": images})
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+		file.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read " + fileHeader.Filename, "images": images})
+			return
+		}
+		image, err := utils.SaveProductImage(product.ID, data)
+		if err != nil {
+			// Images saved before the failing file are kept and returned.
+			respondImageError(c, fmt.Errorf("%s: %w", fileHeader.Filename, err), images)
+			return
+		}
+		images = append(images, image)
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "images uploaded successfully", "images": images})
+}
+
+// GetProductImages godoc
+// @Summary Get product images
+// @Description List a product's images in display order with their thumbnail URLs
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} map[string]interface{} "Images retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/images [get]
+func GetProductImages(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	images, err := utils.ProductImages(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting images"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "images fetched successfully", "images": images})
+}
+
+// ReorderProductImages godoc
+// @Summary Reorder product images
+// @Description Set the display order of a product's images. image_ids must list every image of the product (requires products:write)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param order body ImageOrder true "Image ids in display order"
+// @Success 200 {object} map[string]interface{} "Images reordered successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - image_ids do not match the product's images"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/images/order [put]
+func ReorderProductImages(c *gin.Context) {
+	var product database.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+	var imageOrder ImageOrder
+	if err := c.ShouldBindJSON(&imageOrder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	images, err := utils.ReorderProductImages(product.ID, imageOrder.ImageIDs)
+	if err != nil {
+		respondImageError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "images reordered successfully", "images": images})
+}
+
+// SetPrimaryProductImage godoc
+// @Summary Set the primary product image
+// @Description Make an image the product's primary image (requires products:write)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param imageId path string true "Image ID"
+// @Success 200 {object} map[string]interface{} "Primary image updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Image not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/images/{imageId}/primary [put]
+func SetPrimaryProductImage(c *gin.Context) {
+	productId, imageId, ok := imagePathIDs(c)
+	if !ok {
+		return
+	}
+	if err := utils.SetPrimaryProductImage(productId, imageId); err != nil {
+		respondImageError(c, err, nil)
+		return
+	}
+	images, err := utils.ProductImages(productId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting images"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "primary image updated successfully", "images": images})
+}
+
+// DeleteProductImage godoc
+// @Summary Delete a product image
+// @Description Delete an image and its thumbnails. If it was the primary image, the next image in display order becomes primary (requires products:write)
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param imageId path string true "Image ID"
+// @Success 200 {object} map[string]interface{} "Image deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - products:write permission required"
+// @Failure 404 {object} map[string]interface{} "Image not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /products/{id}/images/{imageId} [delete]
+func DeleteProductImage(c *gin.Context) {
+	productId, imageId, ok := imagePathIDs(c)
+	if !ok {
+		return
+	}
+	if err := utils.DeleteProductImage(productId, imageId); err != nil {
+		respondImageError(c, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "image deleted successfully"})
+}
+
+func imagePathIDs(c *gin.Context) (uint, uint, bool) {
+	productId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		return 0, 0, false
+	}
+	imageId, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image id"})
+		return 0, 0, false
+	}
+	return uint(productId), uint(imageId), true
+}
+
+func respondImageError(c *gin.Context, err error, uploaded []database.ProductImage) {
+	body := gin.H{"error": err.Error()}
+	if uploaded != nil {
+		body["images"] = uploaded
+	}
+	switch {
+	case errors.Is(err, utils.ErrUnsupportedImage), errors.Is(err, utils.ErrImageTooLarge), errors.Is(err, utils.ErrInvalidImageOrder):
+		c.JSON(http.StatusBadRequest, body)
+	case errors.Is(err, utils.ErrProductImageNotFound):
+		c.JSON(http.StatusNotFound, body)
+	default:
+		c.JSON(http.StatusInternalServerError, body)
+	}
+}
@@ -0,0 +1,310 @@
+package returns
+
+import (
+	"errors"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/middleware"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ReturnDetails struct {
+	OrderID uint               `json:"order_id" example:"1"`
+	Reason  string             `json:"reason" example:"does not fit"`
+	Items   []utils.ReturnLine `json:"items"`
+}
+type DecisionDetails struct {
+	Note string `json:"note" example:"approved, print the return label"`
+}
+
+var returnSortColumns = map[string]string{
+	"created_at": "created_at",
+}
+
+// CreateReturn godoc
+// @Summary Request a return
+// @Description Ask to send back items of one of the authenticated user's delivered orders, within RETURN_WINDOW_DAYS (30 by default) of delivery. Items cannot be returned once refunded or while another return of them awaits a decision
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param return body ReturnDetails true "Order, reason and items to return"
+// @Success 201 {object} map[string]interface{} "Return requested successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - unknown item or more than can be returned"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order is not delivered or its return window has closed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /returns [post]
+func CreateReturn(c *gin.Context) {
+	var returnDetails ReturnDetails
+	if err := c.ShouldBindJSON(&returnDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	userId := c.GetUint("userId")
+	var order database.Order
+	if err := database.DB.Where("id = ? AND user_id = ?", returnDetails.OrderID, userId).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	request, err := utils.RequestReturn(order, userId, returnDetails.Reason, returnDetails.Items)
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "return requested successfully", "return": request})
+}
+
+// GetMyReturns godoc
+// @Summary List my returns
+// @Description List the authenticated user's return requests with their items, newest first
+// @Tags returns
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Returns retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /returns/mine [get]
+func GetMyReturns(c *gin.Context) {
+	requests := []database.ReturnRequest{}
+	if err := database.DB.Preload("Items").Where("user_id = ?", c.GetUint("userId")).Order("created_at desc, id desc").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting returns"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "returns fetched successfully", "returns": requests})
+}
+
+// GetReturns godoc
+// @Summary List return requests
+// @Description List return requests with offset (page) or cursor pagination, e.g. status=REQUESTED for those awaiting a decision (requires returns:manage)
+// @Tags returns
+// @Produce json
+// @Param page query int false "Page number (default 1), ignored when cursor is set"
+// @Param page_size query int false "Returns per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param order query string false "asc or desc (default desc)"
+// @Param status query string false "REQUESTED, APPROVING, APPROVED, REJECTED or RECEIVED"
+// @Param order_id query int false "Only returns of this order"
+// @Success 200 {object} map[string]interface{} "Returns retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid filter or pagination parameter"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - returns:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /returns [get]
+func GetReturns(c *gin.Context) {
+	params, err := utils.ParsePageParams(c, returnSortColumns, "created_at", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := database.DB.Model(&database.ReturnRequest{})
+	if status := strings.ToUpper(c.Query("status")); status != "" {
+		switch status {
+		case utils.ReturnStatusRequested, utils.ReturnStatusApproving, utils.ReturnStatusApproved, utils.ReturnStatusRejected, utils.ReturnStatusReceived:
+			query = query.Where("status = ?", status)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be REQUESTED, APPROVING, APPROVED, REJECTED or RECEIVED"})
+			return
+		}
+	}
+	if orderId := c.Query("order_id"); orderId != "" {
+		id, err := strconv.ParseUint(orderId, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
+			return
+		}
+		query = query.Where("order_id = ?", id)
+	}
+	requests, pagination, err := utils.Paginate(query, params, func(request database.ReturnRequest) (interface{}, uint) {
+		return request.CreatedAt, request.ID
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting returns"})
+		return
+	}
+	ids := make([]uint, 0, len(requests))
+	for _, request := range requests {
+		ids = append(ids, request.ID)
+	}
+	var items []database.ReturnItem
+	if len(ids) > 0 {
+		if err := database.DB.Where("return_request_id IN ?", ids).Order("id").Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting return items"})
+			return
+		}
+	}
+	for i := range requests {
+		requests[i].Items = []database.ReturnItem{}
+		for _, item := range items {
+			if item.ReturnRequestID == requests[i].ID {
+				requests[i].Items = append(requests[i].Items, item)
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "returns fetched successfully", "returns": requests, "pagination": pagination})
+}
+
+// GetReturn godoc
+// @Summary Get a return request
+// @Description Get a return request with its items (its requester or returns:manage)
+// @Tags returns
+// @Produce json
+// @Param id path string true "Return request ID"
+// @Success 200 {object} map[string]interface{} "Return retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Return request not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /returns/{id} [get]
+func GetReturn(c *gin.Context) {
+	returnId, ok := parseReturnID(c)
+	if !ok {
+		return
+	}
+	request, err := utils.LoadReturn(database.DB, returnId)
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+	if request.UserID != c.GetUint("userId") && !middleware.HasPermission(c, database.PermReturnsManage) {
+		c.JSON(http.StatusNotFound, gin.H{"error": utils.ErrReturnNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return fetched successfully", "return": request})
+}
+
+// ApproveReturn godoc
+// @Summary Approve a return
+// @Description Approve a requested return and refund its items, with their share of the discount and tax, through the payment gateway. The items are restocked when they are received (requires returns:manage)
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return request ID"
+// @Param decision body DecisionDetails false "Note to the customer"
+// @Success 200 {object} map[string]interface{} "Return approved successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - returns:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Return request not found"
+// @Failure 409 {object} map[string]interface{} "Return was already decided or the order cannot be refunded"
+// @Failure 502 {object} map[string]interface{} "Payment gateway rejected the refund"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /returns/{id}/approve [put]
+func ApproveReturn(c *gin.Context) {
+	returnId, ok := parseReturnID(c)
+	if !ok {
+		return
+	}
+	var decisionDetails DecisionDetails
+	if err := c.ShouldBindJSON(&decisionDetails); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	request, err := utils.ApproveReturn(returnId, c.GetUint("userId"), decisionDetails.Note)
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return approved successfully", "return": request})
+}
+
+// RejectReturn godoc
+// @Summary Reject a return
+// @Description Turn down a requested return, with a note to the customer (requires returns:manage)
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return request ID"
+// @Param decision body DecisionDetails false "Note to the customer"
+// @Success 200 {object} map[string]interface{} "Return rejected successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - returns:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Return request not found"
+// @Failure 409 {object} map[string]interface{} "Return was already decided"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /returns/{id}/reject [put]
+func RejectReturn(c *gin.Context) {
+	returnId, ok := parseReturnID(c)
+	if !ok {
+		return
+	}
+	var decisionDetails DecisionDetails
+	if err := c.ShouldBindJSON(&decisionDetails); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	request, err := utils.RejectReturn(returnId, c.GetUint("userId"), decisionDetails.Note)
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return rejected successfully", "return": request})
+}
+
+// ReceiveReturn godoc
+// @Summary Receive a return
+// @Description Record that the goods of an approved return came back and put them back in stock as a RETURN in the stock ledger (requires returns:manage)
+// @Tags returns
+// @Produce json
+// @Param id path string true "Return request ID"
+// @Success 200 {object} map[string]interface{} "Return received successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - returns:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Return request not found"
+// @Failure 409 {object} map[string]interface{} "Return is not approved or was already received"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /returns/{id}/receive [put]
+func ReceiveReturn(c *gin.Context) {
+	returnId, ok := parseReturnID(c)
+	if !ok {
+		return
+	}
+	request, err := utils.ReceiveReturn(returnId, c.GetUint("userId"))
+	if err != nil {
+		respondReturnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return received successfully", "return": request})
+}
+
+func parseReturnID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid return id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondReturnError(c *gin.Context, err error) {
+	var transitionErr *utils.InvalidTransitionError
+	switch {
+	case errors.Is(err, utils.ErrReturnNotFound), errors.Is(err, utils.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrInvalidReturnItem), errors.Is(err, utils.ErrInvalidRefundItem):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrOrderNotReturnable), errors.Is(err, utils.ErrReturnWindowClosed), errors.Is(err, utils.ErrReturnStatusChanged),
+		errors.Is(err, utils.ErrOrderNotRefundable), errors.Is(err, utils.ErrNothingToRefund), errors.Is(err, utils.ErrRefundExceedsAmount),
+		errors.Is(err, utils.ErrOrderStatusChanged), errors.As(err, &transitionErr):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrRefundFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,253 @@
+package roles
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+type RoleDetails struct {
+	Name        string   `json:"name" example:"support"`
+	Description string   `json:"description" example:"Customer support agents"`
+	Permissions []string `json:"permissions" example:"orders:read_all,orders:refund"`
+}
+type RolePermissions struct {
+	Permissions []string `json:"permissions" example:"orders:read_all,orders:refund"`
+}
+type AssignRoleDetails struct {
+	Role string `json:"role" example:"admin"`
+}
+
+// GetAllRoles godoc
+// @Summary Get all roles
+// @Description List every role with its permissions
+// @Tags roles
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Roles retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - roles:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /roles [get]
+func GetAllRoles(c *gin.Context) {
+	var roles []database.Role
+	if err := database.DB.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting roles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "roles fetched successfully", "roles": roles})
+}
+
+// GetAllPermissions godoc
+// @Summary Get all permissions
+// @Description List every permission that can be granted to a role
+// @Tags roles
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Permissions retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - roles:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /roles/permissions [get]
+func GetAllPermissions(c *gin.Context) {
+	var permissions []database.Permission
+	if err := database.DB.Order("name").Find(&permissions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting permissions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "permissions fetched successfully", "permissions": permissions})
+}
+
+// CreateRole godoc
+// @Summary Create a role
+// @Description Create a role granting the given permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param role body RoleDetails true "Role data"
+// @Success 201 {object} map[string]interface{} "Role created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error, unknown permission or role already exists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - roles:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /roles [post]
+func CreateRole(c *gin.Context) {
+	var roleDetails RoleDetails
+	if err := c.ShouldBindJSON(&roleDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	roleDetails.Name = strings.ToLower(strings.TrimSpace(roleDetails.Name))
+	if roleDetails.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role name is required"})
+		return
+	}
+	var eRole database.Role
+	if err := database.DB.Where("name = ?", roleDetails.Name).First(&eRole).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role already exists"})
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting role"})
+		return
+	}
+	permissions, ok := findPermissions(c, roleDetails.Permissions)
+	if !ok {
+		return
+	}
+	role := database.Role{Name: roleDetails.Name, Description: roleDetails.Description, Permissions: permissions}
+	if err := database.DB.Create(&role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the role"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "role created successfully", "role": role})
+}
+
+// UpdateRolePermissions godoc
+// @Summary Set a role's permissions
+// @Description Replace the permissions granted by a role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param permissions body RolePermissions true "Permissions to grant"
+// @Success 200 {object} map[string]interface{} "Role updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - unknown permission"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - roles:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Role not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /roles/{id}/permissions [put]
+func UpdateRolePermissions(c *gin.Context) {
+	var role database.Role
+	if err := database.DB.First(&role, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+	var rolePermissions RolePermissions
+	if err := c.ShouldBindJSON(&rolePermissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if role.Name == database.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the admin role always has every permission"})
+		return
+	}
+	permissions, ok := findPermissions(c, rolePermissions.Permissions)
+	if !ok {
+		return
+	}
+	if err := database.DB.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the role"})
+		return
+	}
+	role.Permissions = permissions
+	c.JSON(http.StatusOK, gin.H{"message": "role updated successfully", "role": role})
+}
+
+// AssignRole godoc
+// @Summary Assign a role to a user
+// @Description Grant a role to a user. It takes effect on the user's next login or token refresh.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role body AssignRoleDetails true "Role to assign"
+// @Success 200 {object} map[string]interface{} "Role assigned successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - roles:manage permission required"
+// @Failure 404 {object} map[string]interface{} "User or role not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /users/{id}/roles [post]
+func AssignRole(c *gin.Context) {
+	var user database.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	var assignRoleDetails AssignRoleDetails
+	if err := c.ShouldBindJSON(&assignRoleDetails); err != nil || assignRoleDetails.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role is required"})
+		return
+	}
+	var role database.Role
+	if err := database.DB.Where("name = ?", strings.ToLower(assignRoleDetails.Role)).First(&role).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+	if err := database.DB.Model(&user).Association("Roles").Append(&role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while assigning the role"})
+		return
+	}
+	database.DB.Model(&user).Association("Roles").Find(&user.Roles)
+	user.Password = ""
+	c.JSON(http.StatusOK, gin.H{"message": "role assigned successfully", "user": user})
+}
+
+// RemoveRole godoc
+// @Summary Remove a role from a user
+// @Description Take a role away from a user. The user's sessions are revoked so the change applies immediately.
+// @Tags roles
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Success 200 {object} map[string]interface{} "Role removed successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - cannot remove your own admin role"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - roles:manage permission required"
+// @Failure 404 {object} map[string]interface{} "User or role not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /users/{id}/roles/{role} [delete]
+func RemoveRole(c *gin.Context) {
+	userId, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	var user database.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	var role database.Role
+	if err := database.DB.Where("name = ?", strings.ToLower(c.Param("role"))).First(&role).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+	if role.Name == database.RoleAdmin && user.ID == userId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot remove your own admin role"})
+		return
+	}
+	if err := database.DB.Model(&user).Association("Roles").Delete(&role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while removing the role"})
+		return
+	}
+	if err := utils.RevokeUserSessions(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role removed successfully"})
+}
+
+func findPermissions(c *gin.Context, names []string) ([]database.Permission, bool) {
+	permissions := []database.Permission{}
+	if len(names) == 0 {
+		return permissions, true
+	}
+	if err := database.DB.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting permissions"})
+		return nil, false
+	}
+	if len(permissions) != len(names) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown permission in request"})
+		return nil, false
+	}
+	return permissions, true
+}
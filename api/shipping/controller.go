@@ -0,0 +1,242 @@
+package shipping
+
+import (
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/middleware"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type ShippingRateDetails struct {
+	Min   int64          `json:"min" example:"0"`
+	Price database.Money `json:"price"`
+}
+type ShippingMethodDetails struct {
+	Code              string                `json:"code" example:"standard"`
+	Name              string                `json:"name" example:"Standard delivery"`
+	Carrier           string                `json:"carrier" example:"UPS"`
+	Description       string                `json:"description" example:"3 to 5 business days"`
+	Basis             string                `json:"basis" example:"WEIGHT"`
+	Countries         []string              `json:"countries" example:"US,CA"`
+	VolumetricDivisor int                   `json:"volumetric_divisor" example:"5000"`
+	FreeOver          database.Money        `json:"free_over"`
+	Active            *bool                 `json:"active" example:"true"`
+	Rates             []ShippingRateDetails `json:"rates"`
+}
+
+// GetShippingMethods godoc
+// @Summary List shipping methods
+// @Description List the active shipping methods with their rate tables. Users with shipping:manage also see inactive methods. Rate minimums are grams for WEIGHT methods and minor units of the base currency for PRICE methods
+// @Tags shipping
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Shipping methods retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /shipping/methods [get]
+func GetShippingMethods(c *gin.Context) {
+	query := database.DB.Preload("Rates", func(db *gorm.DB) *gorm.DB { return db.Order("min") }).Order("id")
+	if !middleware.HasPermission(c, database.PermShippingManage) {
+		query = query.Where("active = ?", true)
+	}
+	methods := []database.ShippingMethod{}
+	if err := query.Find(&methods).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting shipping methods"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "shipping methods fetched successfully", "shipping_methods": methods})
+}
+
+// CreateShippingMethod godoc
+// @Summary Create a shipping method
+// @Description Create a shipping method with its rate table, priced in the base currency. Each rate applies from its min (grams for WEIGHT, minor units of the order value for PRICE) up to the next one, and one must start at 0. With volumetric_divisor bulky parcels are charged by volume in cm³ divided by it (in kg). Once a method is active, orders must choose one (requires shipping:manage)
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param method body ShippingMethodDetails true "Shipping method data"
+// @Success 201 {object} map[string]interface{} "Shipping method created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error or code already exists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - shipping:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /shipping/methods [post]
+func CreateShippingMethod(c *gin.Context) {
+	var methodDetails ShippingMethodDetails
+	if err := c.ShouldBindJSON(&methodDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	method := database.ShippingMethod{Active: true}
+	if !applyShippingMethodDetails(c, &method, methodDetails) {
+		return
+	}
+	if err := database.DB.Create(&method).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the shipping method"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "shipping method created successfully", "shipping_method": method})
+}
+
+// UpdateShippingMethod godoc
+// @Summary Update a shipping method
+// @Description Replace a shipping method's settings and rate table. Placed orders keep the shipping they were charged (requires shipping:manage)
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param id path string true "Shipping method ID"
+// @Param method body ShippingMethodDetails true "Shipping method data"
+// @Success 200 {object} map[string]interface{} "Shipping method updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error or code already exists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - shipping:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Shipping method not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /shipping/methods/{id} [put]
+func UpdateShippingMethod(c *gin.Context) {
+	var method database.ShippingMethod
+	if err := database.DB.First(&method, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shipping method not found"})
+		return
+	}
+	var methodDetails ShippingMethodDetails
+	if err := c.ShouldBindJSON(&methodDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if !applyShippingMethodDetails(c, &method, methodDetails) {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shipping_method_id = ?", method.ID).Delete(&database.ShippingRate{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&method).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the shipping method"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "shipping method updated successfully", "shipping_method": method})
+}
+
+// DeleteShippingMethod godoc
+// @Summary Delete a shipping method
+// @Description Delete a shipping method and its rates. Placed orders keep the method name and shipping they were charged; deactivate the method instead to keep it on record (requires shipping:manage)
+// @Tags shipping
+// @Produce json
+// @Param id path string true "Shipping method ID"
+// @Success 200 {object} map[string]interface{} "Shipping method deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - shipping:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Shipping method not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /shipping/methods/{id} [delete]
+func DeleteShippingMethod(c *gin.Context) {
+	var method database.ShippingMethod
+	if err := database.DB.First(&method, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shipping method not found"})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shipping_method_id = ?", method.ID).Delete(&database.ShippingRate{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&method).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the shipping method"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "shipping method deleted successfully"})
+}
+
+// applyShippingMethodDetails validates the details and copies them onto the
+// method, replacing its rates.
+func applyShippingMethodDetails(c *gin.Context, method *database.ShippingMethod, methodDetails ShippingMethodDetails) bool {
+	code := strings.ToLower(strings.TrimSpace(methodDetails.Code))
+	name := strings.TrimSpace(methodDetails.Name)
+	if code == "" || name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and name are required"})
+		return false
+	}
+	basis := strings.ToUpper(strings.TrimSpace(methodDetails.Basis))
+	if basis != utils.ShippingBasisWeight && basis != utils.ShippingBasisPrice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "basis must be WEIGHT or PRICE"})
+		return false
+	}
+	if methodDetails.VolumetricDivisor < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "volumetric_divisor cannot be negative"})
+		return false
+	}
+	base := database.DefaultCurrency()
+	if methodDetails.FreeOver.IsSet() && (methodDetails.FreeOver.Currency != base || methodDetails.FreeOver.IsNegative()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "free_over must be an amount in " + base})
+		return false
+	}
+	countries := []string{}
+	for _, country := range methodDetails.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if !utils.ValidCountryCode(country) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", country)})
+			return false
+		}
+		countries = append(countries, country)
+	}
+	if len(methodDetails.Rates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one rate is required"})
+		return false
+	}
+	rates := make([]database.ShippingRate, 0, len(methodDetails.Rates))
+	mins := map[int64]bool{}
+	for _, rate := range methodDetails.Rates {
+		if rate.Min < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rate minimums cannot be negative"})
+			return false
+		}
+		if mins[rate.Min] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("two rates start at %d", rate.Min)})
+			return false
+		}
+		if !rate.Price.IsSet() || rate.Price.Currency != base || rate.Price.IsNegative() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rate prices must be amounts in " + base})
+			return false
+		}
+		mins[rate.Min] = true
+		rates = append(rates, database.ShippingRate{ShippingMethodID: method.ID, Min: rate.Min, Price: rate.Price})
+	}
+	if !mins[0] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a rate must start at min 0"})
+		return false
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Min < rates[j].Min })
+	var count int64
+	if err := database.DB.Model(&database.ShippingMethod{}).Where("code = ? AND id <> ?", code, method.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting shipping methods"})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "shipping method code already exists"})
+		return false
+	}
+	method.Code = code
+	method.Name = name
+	method.Carrier = strings.TrimSpace(methodDetails.Carrier)
+	method.Description = methodDetails.Description
+	method.Basis = basis
+	method.Countries = countries
+	method.VolumetricDivisor = methodDetails.VolumetricDivisor
+	method.FreeOver = methodDetails.FreeOver
+	if methodDetails.Active != nil {
+		method.Active = *methodDetails.Active
+	}
+	method.Rates = rates
+	return true
+}
@@ -0,0 +1,346 @@
+package taxes
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+type TaxClassDetails struct {
+	Name        string `json:"name" example:"reduced"`
+	Description string `json:"description" example:"Books and food"`
+}
+type TaxRateDetails struct {
+	TaxClassID uint    `json:"tax_class_id" example:"1"`
+	Country    string  `json:"country" example:"US"`
+	Region     string  `json:"region" example:"CA"`
+	Name       string  `json:"name" example:"Sales tax"`
+	Rate       float64 `json:"rate" example:"7.25"`
+}
+
+// GetTaxClasses godoc
+// @Summary List tax classes
+// @Description List the tax classes products can be put in. Products without one are in the standard class (requires taxes:manage)
+// @Tags taxes
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Tax classes retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/classes [get]
+func GetTaxClasses(c *gin.Context) {
+	classes := []database.TaxClass{}
+	if err := database.DB.Order("name").Find(&classes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting tax classes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax classes fetched successfully", "tax_classes": classes})
+}
+
+// CreateTaxClass godoc
+// @Summary Create a tax class
+// @Description Create a tax class. Names are stored lower-case and must be unique (requires taxes:manage)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Param class body TaxClassDetails true "Tax class data"
+// @Success 201 {object} map[string]interface{} "Tax class created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - missing name or name already exists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/classes [post]
+func CreateTaxClass(c *gin.Context) {
+	var classDetails TaxClassDetails
+	if err := c.ShouldBindJSON(&classDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	var class database.TaxClass
+	if !applyTaxClassDetails(c, &class, classDetails) {
+		return
+	}
+	if err := database.DB.Create(&class).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the tax class"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "tax class created successfully", "tax_class": class})
+}
+
+// UpdateTaxClass godoc
+// @Summary Update a tax class
+// @Description Rename or describe a tax class. The standard class cannot be renamed. Placed orders keep the class name they were taxed under (requires taxes:manage)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Param id path string true "Tax class ID"
+// @Param class body TaxClassDetails true "Tax class data"
+// @Success 200 {object} map[string]interface{} "Tax class updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - missing name, name already exists or renaming the standard class"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Tax class not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/classes/{id} [put]
+func UpdateTaxClass(c *gin.Context) {
+	var class database.TaxClass
+	if err := database.DB.First(&class, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tax class not found"})
+		return
+	}
+	var classDetails TaxClassDetails
+	if err := c.ShouldBindJSON(&classDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if !applyTaxClassDetails(c, &class, classDetails) {
+		return
+	}
+	if err := database.DB.Save(&class).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the tax class"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax class updated successfully", "tax_class": class})
+}
+
+// DeleteTaxClass godoc
+// @Summary Delete a tax class
+// @Description Delete a tax class that no product is in, with its rates. The standard class cannot be deleted (requires taxes:manage)
+// @Tags taxes
+// @Produce json
+// @Param id path string true "Tax class ID"
+// @Success 200 {object} map[string]interface{} "Tax class deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - the standard class cannot be deleted"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Tax class not found"
+// @Failure 409 {object} map[string]interface{} "Products are still in the tax class"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/classes/{id} [delete]
+func DeleteTaxClass(c *gin.Context) {
+	var class database.TaxClass
+	if err := database.DB.First(&class, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tax class not found"})
+		return
+	}
+	if class.Name == database.TaxClassStandard {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the standard tax class cannot be deleted"})
+		return
+	}
+	var products int64
+	if err := database.DB.Model(&database.Product{}).Where("tax_class_id = ?", class.ID).Count(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting products"})
+		return
+	}
+	if products > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%d products are in this tax class, move them first", products)})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tax_class_id = ?", class.ID).Delete(&database.TaxRate{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&class).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the tax class"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax class deleted successfully"})
+}
+
+// GetTaxRates godoc
+// @Summary List tax rates
+// @Description List tax rates by country, region and class (requires taxes:manage)
+// @Tags taxes
+// @Produce json
+// @Param class_id query int false "Only rates of this tax class"
+// @Param country query string false "Only rates in this country"
+// @Success 200 {object} map[string]interface{} "Tax rates retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/rates [get]
+func GetTaxRates(c *gin.Context) {
+	query := database.DB.Order("country, region, tax_class_id")
+	if classID := c.Query("class_id"); classID != "" {
+		query = query.Where("tax_class_id = ?", classID)
+	}
+	if country := c.Query("country"); country != "" {
+		query = query.Where("country = ?", strings.ToUpper(country))
+	}
+	rates := []database.TaxRate{}
+	if err := query.Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting tax rates"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax rates fetched successfully", "tax_rates": rates})
+}
+
+// CreateTaxRate godoc
+// @Summary Create a tax rate
+// @Description Set the per cent rate of a tax class in a country, or in one of its regions with region. A region's rate replaces its country's, and classes without a rate in a zone are not taxed there (requires taxes:manage)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Param rate body TaxRateDetails true "Tax rate data"
+// @Success 201 {object} map[string]interface{} "Tax rate created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error or the zone already has a rate for the class"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/rates [post]
+func CreateTaxRate(c *gin.Context) {
+	var rateDetails TaxRateDetails
+	if err := c.ShouldBindJSON(&rateDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	var rate database.TaxRate
+	if !applyTaxRateDetails(c, &rate, rateDetails) {
+		return
+	}
+	if err := database.DB.Create(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving the tax rate"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "tax rate created successfully", "tax_rate": rate})
+}
+
+// UpdateTaxRate godoc
+// @Summary Update a tax rate
+// @Description Replace a tax rate's settings. Placed orders keep the rate they were taxed at (requires taxes:manage)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Param id path string true "Tax rate ID"
+// @Param rate body TaxRateDetails true "Tax rate data"
+// @Success 200 {object} map[string]interface{} "Tax rate updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation error or the zone already has a rate for the class"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Tax rate not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/rates/{id} [put]
+func UpdateTaxRate(c *gin.Context) {
+	var rate database.TaxRate
+	if err := database.DB.First(&rate, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tax rate not found"})
+		return
+	}
+	var rateDetails TaxRateDetails
+	if err := c.ShouldBindJSON(&rateDetails); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json data"})
+		return
+	}
+	if !applyTaxRateDetails(c, &rate, rateDetails) {
+		return
+	}
+	if err := database.DB.Save(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the tax rate"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax rate updated successfully", "tax_rate": rate})
+}
+
+// DeleteTaxRate godoc
+// @Summary Delete a tax rate
+// @Description Delete a tax rate. Placed orders keep the tax they were charged (requires taxes:manage)
+// @Tags taxes
+// @Produce json
+// @Param id path string true "Tax rate ID"
+// @Success 200 {object} map[string]interface{} "Tax rate deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - taxes:manage permission required"
+// @Failure 404 {object} map[string]interface{} "Tax rate not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /taxes/rates/{id} [delete]
+func DeleteTaxRate(c *gin.Context) {
+	var rate database.TaxRate
+	if err := database.DB.First(&rate, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tax rate not found"})
+		return
+	}
+	if err := database.DB.Delete(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the tax rate"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax rate deleted successfully"})
+}
+
+// applyTaxClassDetails validates the details and copies them onto the class.
+func applyTaxClassDetails(c *gin.Context, class *database.TaxClass, classDetails TaxClassDetails) bool {
+	name := strings.ToLower(strings.TrimSpace(classDetails.Name))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return false
+	}
+	if class.Name == database.TaxClassStandard && name != database.TaxClassStandard {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the standard tax class cannot be renamed"})
+		return false
+	}
+	var count int64
+	if err := database.DB.Model(&database.TaxClass{}).Where("name = ? AND id <> ?", name, class.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting tax classes"})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tax class already exists"})
+		return false
+	}
+	class.Name = name
+	class.Description = classDetails.Description
+	return true
+}
+
+// applyTaxRateDetails validates the details and copies them onto the rate.
+func applyTaxRateDetails(c *gin.Context, rate *database.TaxRate, rateDetails TaxRateDetails) bool {
+	country := strings.ToUpper(strings.TrimSpace(rateDetails.Country))
+	region := strings.ToUpper(strings.TrimSpace(rateDetails.Region))
+	if !utils.ValidCountryCode(country) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.ErrInvalidTaxZone.Error()})
+		return false
+	}
+	if rateDetails.Rate < 0 || rateDetails.Rate > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rate must be between 0 and 100"})
+		return false
+	}
+	if err := utils.CheckTaxClass(database.DB, &rateDetails.TaxClassID); err != nil {
+		if errors.Is(err, utils.ErrTaxClassNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	var count int64
+	err := database.DB.Model(&database.TaxRate{}).Where("tax_class_id = ? AND country = ? AND region = ? AND id <> ?", rateDetails.TaxClassID, country, region, rate.ID).
+		Count(&count).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting tax rates"})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the tax class already has a rate in this country and region"})
+		return false
+	}
+	rate.TaxClassID = rateDetails.TaxClassID
+	rate.Country = country
+	rate.Region = region
+	rate.Name = strings.TrimSpace(rateDetails.Name)
+	rate.Rate = rateDetails.Rate
+	return true
+}
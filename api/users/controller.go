@@ -1,32 +1,35 @@
 package users
 
 import (
+	"errors"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/middleware"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"log"
 	"net/http"
-	"os"
-	"fmt"
+	"strings"
 )
 
-// jwtKey will be loaded from environment in each function
-
 type UserUpdate struct {
 	Email    string `json:"email" example:"user@example.com"`
 	Password string `json:"password" example:"newpassword123"`
 	Name     string `json:"name" example:"John Doe"`
 }
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"`
+}
 
 // RegisterUser godoc
 // @Summary Register a new user
-// @Description Register a new user account with email, name, password, and optional role
+// @Description Register a new user account with email, name and password. Any roles in the request are ignored; new users always get the "user" role. A guest cart sent with the X-Cart-Token header or cart_token cookie becomes the user's cart, see /users/login.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body database.User true "User registration data"
+// @Param X-Cart-Token header string false "Guest cart token to merge into the user's cart, also accepted as the cart_token cookie"
 // @Success 200 {object} map[string]interface{} "User registered successfully with JWT token"
 // @Failure 400 {object} map[string]interface{} "Bad request - validation error or email already exists"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -54,38 +57,39 @@ func RegisterUser(c *gin.Context) {
 		return
 	}
 	newUser.Password = string(hashedPass)
-	// Allow user to specify their own role during registration
-	// If no role is provided, it will default to "user" as defined in the model
-	if err := database.DB.Create(&newUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error creating user"})
+	// Roles are only granted through the roles API, never self-assigned
+	newUser.ID = 0
+	newUser.Cart = 0
+	newUser.Roles = nil
+	var defaultRole database.Role
+	if err := database.DB.Where("name = ?", database.RoleUser).First(&defaultRole).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "default role not configured"})
 		return
 	}
-	jwtKey := os.Getenv("JWT_SECRET")
-	if jwtKey == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT secret not configured"})
+	newUser.Roles = []database.Role{defaultRole}
+	if err := database.DB.Create(&newUser).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error creating user"})
 		return
 	}
-	fmt.Println("JWT_SECRET for generation:", jwtKey)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":    newUser.ID,
-		"email": newUser.Email,
-	})
-	tokenString, err := token.SignedString([]byte(jwtKey))
+	tokens, err := utils.IssueTokenPair(newUser.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error signing token"})
 		return
 	}
 	newUser.Password = ""
-	c.JSON(http.StatusOK, gin.H{"token": tokenString, "user": newUser})
+	response := gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken, "user": newUser}
+	mergeGuestCart(c, newUser.ID, response)
+	c.JSON(http.StatusOK, response)
 }
 
 // LoginUser godoc
 // @Summary User login
-// @Description Authenticate user with email and password, return JWT token
+// @Description Authenticate user with email and password, return JWT token. A guest cart sent with the X-Cart-Token header or cart_token cookie is merged into the user's cart: quantities of the same item are added up and cut down to the available stock, with cart_warnings listing what could not be kept, and the guest cart is deleted.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param credentials body utils.Credentials true "Login credentials"
+// @Param X-Cart-Token header string false "Guest cart token to merge into the user's cart, also accepted as the cart_token cookie"
 // @Success 200 {object} map[string]interface{} "Login successful with JWT token"
 // @Failure 400 {object} map[string]interface{} "Bad request - invalid credentials"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -101,7 +105,7 @@ func LoginUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "all credentials are required"})
 		return
 	}
-	if err := database.DB.Where("email=?", cred.Email).First(&user).Error; err != nil {
+	if err := database.DB.Preload("Roles").Where("email=?", cred.Email).First(&user).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user not found"})
 		return
 	}
@@ -109,27 +113,73 @@ func LoginUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid password"})
 		return
 	}
-	jwtKey := os.Getenv("JWT_SECRET")
-	if jwtKey == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT secret not configured"})
+	tokens, err := utils.IssueTokenPair(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error generating token"})
 		return
 	}
-	fmt.Println("JWT_SECRET for login generation:", jwtKey)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":    user.ID,
-		"email": user.Email,
-	})
-	tokenString, err := token.SignedString([]byte(jwtKey))
+	user.Password = ""
+	response := gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken, "user": user}
+	mergeGuestCart(c, user.ID, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and refresh token. Reusing a refresh token that was already exchanged revokes the whole session.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param refresh body RefreshRequest true "Refresh token"
+// @Success 200 {object} utils.TokenPair "New token pair"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Invalid, expired or reused refresh token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is required"})
+		return
+	}
+	tokens, err := utils.RotateRefreshToken(req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error generating token"})
+		if errors.Is(err, utils.ErrInvalidRefreshToken) || errors.Is(err, utils.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current session so its access and refresh tokens can no longer be used
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logged out successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security BearerAuth
+// @Router /users/logout [post]
+func Logout(c *gin.Context) {
+	sessionId, exists := c.Get("sessionId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to continue"})
+		return
+	}
+	if err := utils.RevokeSession(sessionId.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": tokenString, "user": user})
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 }
 
 // UpdateUser godoc
 // @Summary Update user information
-// @Description Update user details by ID (admin only)
+// @Description Update user details by ID (requires users:write)
 // @Tags users
 // @Accept json
 // @Produce json
@@ -138,6 +188,7 @@ func LoginUser(c *gin.Context) {
 // @Success 200 {object} database.User "User updated successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request - invalid data"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - users:write permission required"
 // @Failure 404 {object} map[string]interface{} "User not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
@@ -206,41 +257,84 @@ func DeleteYourAccount(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while deleting the user account"})
 		return
 	}
+	if err := utils.RevokeUserSessions(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "user account deleted successfully"})
 }
 
 // GetAllUsers godoc
 // @Summary Get all users
-// @Description Retrieve all users (admin only)
+// @Description List users with offset (page) or cursor pagination (requires users:read)
 // @Tags users
 // @Produce json
+// @Param page query int false "Page number (default 1), ignored when cursor is set"
+// @Param page_size query int false "Users per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort by name, email or created_at (default created_at)"
+// @Param order query string false "asc or desc (default desc)"
+// @Param q query string false "Name or email contains (case insensitive)"
 // @Success 200 {object} map[string]interface{} "Users retrieved successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
-// @Failure 401 {object} map[string]interface{} "Unauthorized - admin access required"
-// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden - users:read permission required"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
 // @Router /users/all [get]
 func GetAllUsers(c *gin.Context) {
-	userId, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+	params, err := utils.ParsePageParams(c, userSortColumns, "created_at", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	var user database.User
-	if err := database.DB.First(&user, userId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-		return
+	query := database.DB.Model(&database.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(users.name) LIKE ? OR LOWER(users.email) LIKE ?", like, like)
 	}
-	if user.Role == "user" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "you are unauthorised to get all the users"})
+	users, pagination, err := utils.Paginate(query, params, func(user database.User) (interface{}, uint) {
+		switch params.Sort {
+		case "name":
+			return user.Name, user.ID
+		case "email":
+			return user.Email, user.ID
+		}
+		return user.CreatedAt, user.ID
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting users"})
 		return
 	}
-	var users []database.User
-	if err := database.DB.Find(&users).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"users": users})
+	// Roles are loaded separately because Preload does not mix with Count
+	userIds := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.ID)
+	}
+	var withRoles []database.User
+	if err := database.DB.Preload("Roles").Where("id IN ?", userIds).Find(&withRoles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting user roles"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"users": users, "message": "users fetched successfully"})
+	rolesByUser := map[uint][]database.Role{}
+	for _, user := range withRoles {
+		rolesByUser[user.ID] = user.Roles
+	}
+	for i := range users {
+		users[i].Roles = rolesByUser[users[i].ID]
+		users[i].Password = ""
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users, "pagination": pagination, "message": "users fetched successfully"})
+}
+
+var userSortColumns = map[string]string{
+	"name":       "users.name",
+	"email":      "users.email",
+	"created_at": "users.created_at",
 }
 
 // GetYourAccount godoc
@@ -259,9 +353,29 @@ func GetYourAccount(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "login to access your private information"})
 	}
 	var user database.User
-	if err := database.DB.Where("id = ?", userId).First(&user).Error; err != nil {
+	if err := database.DB.Preload("Roles").Where("id = ?", userId).First(&user).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user not found"})
 		return
 	}
+	user.Password = ""
 	c.JSON(http.StatusOK, user)
 }
+
+// mergeGuestCart merges the guest cart sent with the request into the user's
+// cart and adds what could not be kept to the response. A failed merge does
+// not fail the login; the guest cart is left for the next one.
+func mergeGuestCart(c *gin.Context, userID uint, response gin.H) {
+	token := middleware.CartToken(c)
+	if token == "" {
+		return
+	}
+	warnings, err := utils.MergeGuestCart(userID, token)
+	if err != nil {
+		log.Println("failed to merge the guest cart:", err)
+		return
+	}
+	middleware.ClearCartToken(c)
+	if len(warnings) > 0 {
+		response["cart_warnings"] = warnings
+	}
+}
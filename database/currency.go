@@ -0,0 +1,25 @@
+package database
+
+import "log"
+
+// migrateCurrencies adds the unique index of product price lists, which
+// spans a Money column, and fills the base-currency amounts of orders and
+// payments made before there was more than one currency.
+func migrateCurrencies() {
+	if err := DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_product_prices_item ON product_prices (product_id, variant_id, price_currency)`).Error; err != nil {
+		log.Println("failed to migrate currencies", err)
+		return
+	}
+	statements := []string{
+		`UPDATE orders SET base_grand_total_amount = grand_total_amount, base_grand_total_currency = grand_total_currency
+			WHERE base_grand_total_currency = '' AND grand_total_currency = ?`,
+		`UPDATE payments SET base_amount_amount = amount_amount, base_amount_currency = amount_currency
+			WHERE base_amount_currency = '' AND amount_currency = ?`,
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement, DefaultCurrency()).Error; err != nil {
+			log.Println("failed to migrate currencies", err)
+			return
+		}
+	}
+}
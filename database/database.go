@@ -21,5 +21,13 @@ func Connect() {
 		panic("failed to connect to database " + err.Error())
 	}
 	DB = connection
-	DB.AutoMigrate(&Product{}, &User{}, &Order{}, &OrderItem{}, &Cart{}, &CartItem{}, &Payment{}) // to be done after entity creation
+	DB.AutoMigrate(&Permission{}, &Role{}, &Category{}, &Product{}, &ProductVariant{}, &ProductImage{}, &ProductPrice{}, &ExchangeRate{}, &User{}, &Address{}, &Order{}, &OrderStatusHistory{}, &OrderItem{}, &OrderDiscount{}, &Cart{}, &CartItem{}, &StockReservation{}, &StockMovement{}, &StockAlert{}, &Coupon{}, &CouponRedemption{}, &TaxClass{}, &TaxRate{}, &ShippingMethod{}, &ShippingRate{}, &Shipment{}, &ShipmentItem{}, &Payment{}, &RefreshToken{}, &Refund{}, &RefundItem{}, &ReturnRequest{}, &ReturnItem{}, &IdempotencyKey{}) // to be done after entity creation
+	migrateMoneyColumns()
+	migrateProductSearch()
+	migrateCurrencies()
+	migrateTaxes()
+	migrateOrderTotals()
+	migrateOrderRefundStatus()
+	SeedStockLedger()
+	SeedRBAC()
 }
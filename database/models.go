@@ -2,13 +2,85 @@ package database
 
 import "time"
 
+// Product weight and dimensions are of one packed unit and price shipping.
 type Product struct {
+	ID                uint             `json:"id" gorm:"primaryKey" example:"1"`
+	Name              string           `json:"name" example:"iPhone 15"`
+	Description       string           `json:"description" example:"Latest iPhone model with advanced features"`
+	Price             Money            `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	StockQty          int              `json:"stock_qty" example:"50"`
+	AvailableQty      int              `json:"available_qty" gorm:"-" example:"48"`
+	LowStockThreshold int              `json:"low_stock_threshold" example:"0"`
+	TaxClassID        *uint            `json:"tax_class_id" example:"1"`
+	WeightGrams       int              `json:"weight_grams" example:"171"`
+	LengthMm          int              `json:"length_mm" example:"147"`
+	WidthMm           int              `json:"width_mm" example:"72"`
+	HeightMm          int              `json:"height_mm" example:"8"`
+	CreateAt          time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	Categories        []Category       `json:"categories,omitempty" gorm:"many2many:product_categories"`
+	Variants          []ProductVariant `json:"variants,omitempty"`
+	Images            []ProductImage   `json:"images,omitempty"`
+	Prices            []ProductPrice   `json:"-"`
+}
+
+// ProductPrice is a product's or variant's list price in a currency other
+// than the base currency. Without one, the base price is converted at the
+// currency's exchange rate.
+type ProductPrice struct {
+	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
+	ProductID uint      `json:"product_id" example:"1"`
+	VariantID uint      `json:"variant_id" gorm:"not null;default:0" example:"0"`
+	Price     Money     `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExchangeRate is how many units of Currency one unit of the base currency
+// (DEFAULT_CURRENCY) buys.
+type ExchangeRate struct {
+	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
+	Currency  string    `json:"currency" gorm:"size:3;uniqueIndex" example:"EUR"`
+	Rate      float64   `json:"rate" gorm:"type:numeric(20,10)" example:"0.92"`
+	Source    string    `json:"source" example:"manual"`
+	UpdatedBy uint      `json:"updated_by" example:"1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+type ProductImage struct {
+	ID            uint              `json:"id" gorm:"primaryKey" example:"1"`
+	ProductID     uint              `json:"product_id" gorm:"index" example:"1"`
+	StorageKey    string            `json:"-"`
+	URL           string            `json:"url" example:"/uploads/products/1/Xk3v9QpLr2aB.jpg"`
+	ContentType   string            `json:"content_type" example:"image/jpeg"`
+	Size          int64             `json:"size" example:"204800"`
+	Width         int               `json:"width" example:"1200"`
+	Height        int               `json:"height" example:"900"`
+	Position      int               `json:"position" example:"0"`
+	IsPrimary     bool              `json:"is_primary" example:"true"`
+	Thumbnails    map[string]string `json:"thumbnails" gorm:"serializer:json"`
+	ThumbnailKeys map[string]string `json:"-" gorm:"serializer:json"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+type ProductVariant struct {
+	ID         uint              `json:"id" gorm:"primaryKey" example:"1"`
+	ProductID  uint              `json:"product_id" gorm:"index" example:"1"`
+	SKU        string            `json:"sku" gorm:"uniqueIndex" example:"TSHIRT-RED-M"`
+	Attributes map[string]string `json:"attributes" gorm:"serializer:json"`
+	// An unset Price means the product's price applies.
+	Price             Money     `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	StockQty          int       `json:"stock_qty" example:"10"`
+	AvailableQty      int       `json:"available_qty" gorm:"-" example:"8"`
+	LowStockThreshold int       `json:"low_stock_threshold" example:"0"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+type Category struct {
 	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
-	Name        string    `json:"name" example:"iPhone 15"`
-	Description string    `json:"description" example:"Latest iPhone model with advanced features"`
-	Price       float64   `json:"price" example:"999.99"`
-	StockQty    int       `json:"stock_qty" example:"50"`
-	CreateAt    time.Time `json:"created_at"`
+	Name        string    `json:"name" example:"Phones"`
+	Description string    `json:"description" example:"Smartphones and accessories"`
+	ParentID    *uint     `json:"parent_id" gorm:"index" example:"1"`
+	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 type User struct {
@@ -16,30 +88,127 @@ type User struct {
 	Name      string    `json:"name" example:"John Doe"`
 	Email     string    `json:"email" gorm:"uniqueIndex" example:"john@example.com"`
 	Password  string    `json:"password" example:"hashedpassword"`
-	Role      string    `json:"role" gorm:"default:user" example:"user"`
+	Roles     []Role    `json:"roles" gorm:"many2many:user_roles"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Cart      uint      `example:"1"`
 }
 
+// PostalAddress is where an order is shipped or billed to. Orders keep a
+// copy of it so later edits to the address book never change them.
+type PostalAddress struct {
+	Name       string `json:"name" example:"John Doe"`
+	Line1      string `json:"line1" example:"1 Market Street"`
+	Line2      string `json:"line2" example:"Apartment 4"`
+	City       string `json:"city" example:"San Francisco"`
+	Region     string `json:"region" example:"CA"`
+	PostalCode string `json:"postal_code" example:"94105"`
+	Country    string `json:"country" gorm:"size:2" example:"US"`
+	Phone      string `json:"phone" example:"+14155550100"`
+}
+
+// Address is an entry of a user's address book. A user has at most one
+// default shipping and one default billing address.
+type Address struct {
+	ID     uint `json:"id" gorm:"primaryKey" example:"1"`
+	UserID uint `json:"user_id" gorm:"index" example:"1"`
+	PostalAddress
+	IsDefaultShipping bool      `json:"is_default_shipping" example:"true"`
+	IsDefaultBilling  bool      `json:"is_default_billing" example:"true"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey" example:"1"`
+	Name        string       `json:"name" gorm:"uniqueIndex" example:"admin"`
+	Description string       `json:"description" example:"Full access to the store"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+type Permission struct {
+	ID          uint   `json:"id" gorm:"primaryKey" example:"1"`
+	Name        string `json:"name" gorm:"uniqueIndex" example:"products:write"`
+	Description string `json:"description" example:"Create, update and delete products"`
+}
+
 type Order struct {
-	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	UserId    uint      `json:"user_id" example:"1"`
-	Status    string    `json:"status" example:"PENDING"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Cart      uint      `example:"1"`
+	ID     uint   `json:"id" gorm:"primaryKey" example:"1"`
+	UserId uint   `json:"user_id" example:"1"`
+	Status string `json:"status" example:"PENDING"`
+	// RefundStatus is PARTIALLY_REFUNDED or REFUNDED once money has been
+	// given back; Status keeps following the order's fulfilment.
+	RefundStatus string `json:"refund_status,omitempty" gorm:"not null;default:''" example:"PARTIALLY_REFUNDED"`
+	// Totals are fixed when the order is placed; payments charge GrandTotal.
+	Subtotal      Money `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	DiscountTotal Money `json:"discount_total" gorm:"embedded;embeddedPrefix:discount_total_"`
+	TaxTotal      Money `json:"tax_total" gorm:"embedded;embeddedPrefix:tax_total_"`
+	ShippingTotal Money `json:"shipping_total" gorm:"embedded;embeddedPrefix:shipping_total_"`
+	GrandTotal    Money `json:"grand_total" gorm:"embedded;embeddedPrefix:grand_total_"`
+	// ExchangeRate is the rate of GrandTotal's currency when the order was
+	// placed and BaseGrandTotal the grand total in the base currency at it.
+	ExchangeRate   float64 `json:"exchange_rate" gorm:"type:numeric(20,10);not null;default:1" example:"0.92"`
+	BaseGrandTotal Money   `json:"base_grand_total" gorm:"embedded;embeddedPrefix:base_grand_total_"`
+	// TaxInclusive records whether the item prices included their tax, in
+	// which case TaxTotal is part of Subtotal rather than added to it.
+	TaxInclusive bool   `json:"tax_inclusive" example:"false"`
+	TaxCountry   string `json:"tax_country" gorm:"size:2" example:"US"`
+	TaxRegion    string `json:"tax_region" example:"CA"`
+	// The addresses are copies of the address book entries they came from.
+	ShippingAddressID uint            `json:"shipping_address_id" example:"1"`
+	ShippingAddress   PostalAddress   `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_address_"`
+	BillingAddressID  uint            `json:"billing_address_id" example:"1"`
+	BillingAddress    PostalAddress   `json:"billing_address" gorm:"embedded;embeddedPrefix:billing_address_"`
+	ShippingMethodID  uint            `json:"shipping_method_id" example:"1"`
+	ShippingMethod    string          `json:"shipping_method" example:"Standard delivery"`
+	CancelReason      string          `json:"cancel_reason,omitempty" example:"ordered the wrong size"`
+	CancelledAt       *time.Time      `json:"cancelled_at,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	Cart              uint            `example:"1"`
+	Discounts         []OrderDiscount `json:"discounts,omitempty"`
+}
+type OrderDiscount struct {
+	ID          uint   `json:"id" gorm:"primaryKey" example:"1"`
+	OrderID     uint   `json:"order_id" gorm:"index" example:"1"`
+	CouponID    uint   `json:"coupon_id" example:"1"`
+	Code        string `json:"code" example:"SUMMER10"`
+	Type        string `json:"type" example:"PERCENTAGE"`
+	Description string `json:"description" example:"10% off everything"`
+	Amount      Money  `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+}
+type OrderStatusHistory struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	OrderID    uint      `json:"order_id" gorm:"index" example:"1"`
+	FromStatus string    `json:"from_status" example:"PENDING"`
+	ToStatus   string    `json:"to_status" example:"PAID"`
+	ChangedBy  uint      `json:"changed_by" example:"1"`
+	Note       string    `json:"note" example:"payment captured"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 type OrderItem struct {
-	ID        uint    `json:"id" gorm:"primaryKey" example:"1"`
-	OrderId   uint    `json:"order_id" example:"1"`
-	ProductId uint    `json:"product_id" example:"1"`
-	Quantity  int     `json:"quantity" example:"2"`
-	Price     float64 `json:"price" example:"999.99"`
+	ID                 uint              `json:"id" gorm:"primaryKey" example:"1"`
+	OrderId            uint              `json:"order_id" example:"1"`
+	ProductId          uint              `json:"product_id" example:"1"`
+	VariantId          uint              `json:"variant_id,omitempty" gorm:"not null;default:0" example:"1"`
+	SKU                string            `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	VariantAttributes  map[string]string `json:"variant_attributes,omitempty" gorm:"serializer:json"`
+	ProductName        string            `json:"product_name" example:"iPhone 15"`
+	ProductDescription string            `json:"product_description" example:"Latest iPhone model with advanced features"`
+	Quantity           int               `json:"quantity" example:"2"`
+	Price              Money             `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Discount           Money             `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	// Tax is the tax of the whole line after its discount, at TaxRate per
+	// cent of the tax class the product was in when the order was placed.
+	TaxClass string  `json:"tax_class" example:"standard"`
+	TaxName  string  `json:"tax_name" example:"Sales tax"`
+	TaxRate  float64 `json:"tax_rate" gorm:"type:numeric(9,4);not null;default:0" example:"7.25"`
+	Tax      Money   `json:"tax" gorm:"embedded;embeddedPrefix:tax_"`
 }
 type Cart struct {
 	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
 	UserId    uint      `json:"user_id" example:"1"`
+	CouponID  *uint     `json:"coupon_id" example:"1"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	CartItems []CartItem
@@ -48,16 +217,242 @@ type CartItem struct {
 	ID        uint `json:"id" gorm:"primaryKey" example:"1"`
 	CartId    uint `json:"cart_id" example:"1"`
 	ProductId uint `json:"product_id" example:"1"`
+	VariantId uint `json:"variant_id" gorm:"not null;default:0" example:"0"`
 	Quantity  int  `json:"quantity" example:"2"`
 }
+type StockReservation struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	CartItemID uint      `json:"cart_item_id" gorm:"uniqueIndex" example:"1"`
+	CartID     uint      `json:"cart_id" gorm:"index" example:"1"`
+	ProductID  uint      `json:"product_id" gorm:"index:idx_stock_reservations_item" example:"1"`
+	VariantID  uint      `json:"variant_id" gorm:"index:idx_stock_reservations_item" example:"0"`
+	Quantity   int       `json:"quantity" example:"2"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+type StockMovement struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	ProductID  uint      `json:"product_id" gorm:"index:idx_stock_movements_item" example:"1"`
+	VariantID  uint      `json:"variant_id" gorm:"index:idx_stock_movements_item" example:"0"`
+	Type       string    `json:"type" gorm:"index" example:"SALE"`
+	Quantity   int       `json:"quantity" example:"-2"`
+	StockAfter int       `json:"stock_after" example:"48"`
+	Reason     string    `json:"reason" example:"order placed"`
+	Reference  string    `json:"reference" example:"order:12"`
+	ActorID    uint      `json:"actor_id" example:"1"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+type StockAlert struct {
+	ID         uint       `json:"id" gorm:"primaryKey" example:"1"`
+	ProductID  uint       `json:"product_id" gorm:"index" example:"1"`
+	VariantID  uint       `json:"variant_id" example:"0"`
+	StockQty   int        `json:"stock_qty" example:"3"`
+	Threshold  int        `json:"threshold" example:"5"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+type Coupon struct {
+	ID          uint   `json:"id" gorm:"primaryKey" example:"1"`
+	Code        string `json:"code" gorm:"uniqueIndex" example:"SUMMER10"`
+	Description string `json:"description" example:"10% off everything"`
+	Type        string `json:"type" example:"PERCENTAGE"`
+	// Value is the percentage off of PERCENTAGE coupons and Amount the
+	// amount off of FIXED_AMOUNT ones.
+	Value     float64    `json:"value" example:"10"`
+	Amount    Money      `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	MinSpend  Money      `json:"min_spend" gorm:"embedded;embeddedPrefix:min_spend_"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// UsageLimit and PerUserLimit of 0 mean unlimited.
+	UsageLimit   int       `json:"usage_limit" example:"100"`
+	PerUserLimit int       `json:"per_user_limit" example:"1"`
+	UsedCount    int       `json:"used_count" example:"12"`
+	ProductIDs   []uint    `json:"product_ids" gorm:"serializer:json"`
+	CategoryIDs  []uint    `json:"category_ids" gorm:"serializer:json"`
+	Active       bool      `json:"active" example:"true"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TaxClass groups products taxed alike, e.g. standard, reduced or exempt.
+// Products without one are in the standard class.
+type TaxClass struct {
+	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
+	Name        string    `json:"name" gorm:"uniqueIndex" example:"reduced"`
+	Description string    `json:"description" example:"Books and food"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TaxRate is the per cent rate of a tax class in a country, or in one of its
+// regions when Region is set. A region's rate replaces its country's.
+type TaxRate struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	TaxClassID uint      `json:"tax_class_id" gorm:"uniqueIndex:idx_tax_rates_zone" example:"1"`
+	Country    string    `json:"country" gorm:"size:2;uniqueIndex:idx_tax_rates_zone" example:"US"`
+	Region     string    `json:"region" gorm:"not null;default:'';uniqueIndex:idx_tax_rates_zone" example:"CA"`
+	Name       string    `json:"name" example:"Sales tax"`
+	Rate       float64   `json:"rate" gorm:"type:numeric(9,4)" example:"7.25"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ShippingMethod is a way of shipping orders, priced by a table of rates on
+// the parcel's weight or on the order's value.
+type ShippingMethod struct {
+	ID          uint   `json:"id" gorm:"primaryKey" example:"1"`
+	Code        string `json:"code" gorm:"uniqueIndex" example:"standard"`
+	Name        string `json:"name" example:"Standard delivery"`
+	Carrier     string `json:"carrier" example:"UPS"`
+	Description string `json:"description" example:"3 to 5 business days"`
+	// Basis is WEIGHT (rates by grams) or PRICE (rates by minor units of
+	// the base currency).
+	Basis string `json:"basis" example:"WEIGHT"`
+	// Countries the method ships to, empty for everywhere.
+	Countries []string `json:"countries" gorm:"serializer:json"`
+	// VolumetricDivisor, when set, charges bulky parcels by their volume in
+	// cubic centimetres divided by it (in kilograms) instead of their weight.
+	VolumetricDivisor int `json:"volumetric_divisor" example:"5000"`
+	// FreeOver makes the method free for orders worth at least this much.
+	FreeOver  Money          `json:"free_over" gorm:"embedded;embeddedPrefix:free_over_"`
+	Active    bool           `json:"active" example:"true"`
+	Rates     []ShippingRate `json:"rates"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// ShippingRate is the price of a shipping method from Min upwards, until
+// the next rate's Min.
+type ShippingRate struct {
+	ID               uint  `json:"id" gorm:"primaryKey" example:"1"`
+	ShippingMethodID uint  `json:"shipping_method_id" gorm:"index" example:"1"`
+	Min              int64 `json:"min" example:"0"`
+	Price            Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+}
+
+// Shipment is a parcel of some or all of an order's items.
+type Shipment struct {
+	ID             uint           `json:"id" gorm:"primaryKey" example:"1"`
+	OrderID        uint           `json:"order_id" gorm:"index" example:"1"`
+	Carrier        string         `json:"carrier" example:"UPS"`
+	TrackingNumber string         `json:"tracking_number" example:"1Z999AA10123456784"`
+	Status         string         `json:"status" example:"SHIPPED"`
+	ShippedAt      time.Time      `json:"shipped_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	CreatedBy      uint           `json:"created_by" example:"1"`
+	Items          []ShipmentItem `json:"items"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+type ShipmentItem struct {
+	ID          uint `json:"id" gorm:"primaryKey" example:"1"`
+	ShipmentID  uint `json:"shipment_id" gorm:"index" example:"1"`
+	OrderItemID uint `json:"order_item_id" example:"1"`
+	Quantity    int  `json:"quantity" example:"1"`
+}
+type CouponRedemption struct {
+	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
+	CouponID  uint      `json:"coupon_id" gorm:"index" example:"1"`
+	UserID    uint      `json:"user_id" gorm:"index" example:"1"`
+	OrderID   uint      `json:"order_id" gorm:"index" example:"1"`
+	CreatedAt time.Time `json:"created_at"`
+}
 
 type Payment struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	OrderID       uint      `json:"order_id"`
-	Amount        float64   `json:"amount"`
-	Status        string    `json:"status"`
-	PaymentMethod string    `json:"payment_method"`
-	TransactionID string    `json:"transaction_id"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	OrderID         uint      `json:"order_id"`
+	Amount          Money     `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	ExchangeRate    float64   `json:"exchange_rate" gorm:"type:numeric(20,10);not null;default:1"`
+	BaseAmount      Money     `json:"base_amount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	Status          string    `json:"status"`
+	PaymentMethod   string    `json:"payment_method"`
+	TransactionID   string    `json:"transaction_id"`
+	Gateway         string    `json:"gateway"`
+	GatewayCode     string    `json:"gateway_code"`
+	GatewayMessage  string    `json:"gateway_message"`
+	GatewayResponse string    `json:"-" gorm:"type:text"`
+	RefundedAmount  Money     `json:"refunded_amount" gorm:"embedded;embeddedPrefix:refunded_amount_"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type Refund struct {
+	ID             uint         `json:"id" gorm:"primaryKey" example:"1"`
+	PaymentID      uint         `json:"payment_id" gorm:"index" example:"1"`
+	OrderID        uint         `json:"order_id" gorm:"index" example:"1"`
+	Amount         Money        `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Reason         string       `json:"reason" example:"damaged item"`
+	Status         string       `json:"status" example:"SUCCEEDED"`
+	TransactionID  string       `json:"transaction_id"`
+	GatewayCode    string       `json:"gateway_code"`
+	GatewayMessage string       `json:"gateway_message"`
+	CreatedBy      uint         `json:"created_by" example:"1"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Items          []RefundItem `json:"items"`
+}
+type RefundItem struct {
+	ID          uint  `json:"id" gorm:"primaryKey" example:"1"`
+	RefundID    uint  `json:"refund_id" gorm:"index" example:"1"`
+	OrderItemID uint  `json:"order_item_id" example:"1"`
+	ProductId   uint  `json:"product_id" example:"1"`
+	VariantId   uint  `json:"variant_id,omitempty" gorm:"not null;default:0" example:"1"`
+	Quantity    int   `json:"quantity" example:"1"`
+	Amount      Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+// ReturnRequest is a customer's request to send back items of a delivered
+// order. Approving it refunds the items; receiving them restocks them.
+type ReturnRequest struct {
+	ID         uint         `json:"id" gorm:"primaryKey" example:"1"`
+	OrderID    uint         `json:"order_id" gorm:"index" example:"1"`
+	UserID     uint         `json:"user_id" gorm:"index" example:"1"`
+	Status     string       `json:"status" gorm:"index" example:"REQUESTED"`
+	Reason     string       `json:"reason" example:"does not fit"`
+	Note       string       `json:"note" example:"approved, print the return label"`
+	RefundID   *uint        `json:"refund_id" example:"1"`
+	DecidedBy  uint         `json:"decided_by" example:"1"`
+	DecidedAt  *time.Time   `json:"decided_at"`
+	ReceivedBy uint         `json:"received_by" example:"1"`
+	ReceivedAt *time.Time   `json:"received_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	Items      []ReturnItem `json:"items"`
+}
+type ReturnItem struct {
+	ID              uint   `json:"id" gorm:"primaryKey" example:"1"`
+	ReturnRequestID uint   `json:"return_request_id" gorm:"index" example:"1"`
+	OrderItemID     uint   `json:"order_item_id" example:"1"`
+	ProductId       uint   `json:"product_id" example:"1"`
+	VariantId       uint   `json:"variant_id,omitempty" gorm:"not null;default:0" example:"1"`
+	Quantity        int    `json:"quantity" example:"1"`
+	Reason          string `json:"reason" example:"too small"`
+}
+
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserId    uint       `json:"user_id" gorm:"index"`
+	FamilyID  string     `json:"family_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IdempotencyKey remembers a request made with an Idempotency-Key header and
+// the response it got, so a retry of it is answered with the same response.
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"uniqueIndex:idx_idempotency_keys_user_key"`
+	Key          string    `json:"key" gorm:"size:255;uniqueIndex:idx_idempotency_keys_user_key"`
+	Fingerprint  string    `json:"fingerprint"`
+	Status       string    `json:"status"`
+	ResponseCode int       `json:"response_code"`
+	ContentType  string    `json:"content_type"`
+	ResponseBody string    `json:"response_body"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -0,0 +1,378 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major unit.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Money is an amount in the minor units of its currency (cents for USD), so
+// sums and comparisons are exact. It is stored as two columns through
+// `gorm:"embedded;embeddedPrefix:..."` and marshalled as
+// {"amount": "999.99", "currency": "USD"}. A Money without a currency is
+// unset and marshals as null.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null;default:0" swaggertype:"string" example:"999.99"`
+	Currency string `json:"currency" gorm:"size:3;not null;default:''" example:"USD"`
+}
+
+// DefaultCurrency is the ISO 4217 code in DEFAULT_CURRENCY, USD when unset.
+func DefaultCurrency() string {
+	if currency := strings.ToUpper(os.Getenv("DEFAULT_CURRENCY")); ValidCurrency(currency) {
+		return currency
+	}
+	return "USD"
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+	return currencyPattern.MatchString(code)
+}
+
+// CurrencyExponent is the number of decimal places of a currency's minor unit.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// NewMoney returns amount minor units of currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney reads a decimal such as "999.99" in currency. More decimal places
+// than the currency has are rejected rather than rounded.
+func ParseMoney(value string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return Money{}, fmt.Errorf("%w: unknown currency %q", ErrInvalidMoney, currency)
+	}
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	digits := strings.TrimLeft(value, "+-")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	exponent := CurrencyExponent(currency)
+	if whole == "" || len(value)-len(digits) > 1 || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidMoney, value)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%w: %s amounts have at most %d decimal places", ErrInvalidMoney, currency, exponent)
+	}
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSet reports whether the money has a currency.
+func (m Money) IsSet() bool {
+	return m.Currency != ""
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add, Sub, Cmp and the other helpers taking two amounts panic when both are
+// set in different currencies; an unset operand takes the other's currency.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.commonCurrency(other)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.commonCurrency(other)}
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul multiplies the amount by a quantity.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// MulRatio returns m * numerator / denominator rounded half to even.
+func (m Money) MulRatio(numerator, denominator int64) Money {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(numerator))
+	return Money{Amount: divRoundHalfEven(product, big.NewInt(denominator)).Int64(), Currency: m.Currency}
+}
+
+// Percent returns percent per cent of m, to a hundredth of a per cent,
+// rounded half to even.
+func (m Money) Percent(percent float64) Money {
+	return m.MulRatio(int64(math.Round(percent*100)), 10000)
+}
+
+// Allocate splits m in proportion to weights. Each share is the rounded
+// running total less the shares before it, so the shares always add up to m.
+func (m Money) Allocate(weights []int64) []Money {
+	shares := make([]Money, len(weights))
+	var total, running int64
+	for _, weight := range weights {
+		total += weight
+	}
+	allocated := Money{Currency: m.Currency}
+	for i, weight := range weights {
+		running += weight
+		cumulative := Money{Currency: m.Currency}
+		if total != 0 {
+			cumulative = m.MulRatio(running, total)
+		}
+		shares[i] = cumulative.Sub(allocated)
+		allocated = cumulative
+	}
+	return shares
+}
+
+// Convert returns m in currency at rate, the units of currency one unit of
+// m's currency buys, rounded half to even to currency's minor unit.
+func (m Money) Convert(currency string, rate *big.Rat) Money {
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	shift := CurrencyExponent(currency) - CurrencyExponent(m.Currency)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift < 0 {
+		scale.Inv(scale)
+	}
+	converted.Mul(converted, scale)
+	return Money{Amount: divRoundHalfEven(converted.Num(), converted.Denom()).Int64(), Currency: currency}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Cmp returns -1, 0 or 1 as m is less than, equal to or greater than other.
+func (m Money) Cmp(other Money) int {
+	m.commonCurrency(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) LessThan(other Money) bool {
+	return m.Cmp(other) < 0
+}
+
+func (m Money) GreaterThan(other Money) bool {
+	return m.Cmp(other) > 0
+}
+
+// Min returns the smaller of two amounts.
+func (m Money) Min(other Money) Money {
+	if other.LessThan(m) {
+		return Money{Amount: other.Amount, Currency: m.commonCurrency(other)}
+	}
+	return Money{Amount: m.Amount, Currency: m.commonCurrency(other)}
+}
+
+// SameCurrency reports whether the amounts can be combined.
+func (m Money) SameCurrency(other Money) bool {
+	return !m.IsSet() || !other.IsSet() || m.Currency == other.Currency
+}
+
+func (m Money) commonCurrency(other Money) string {
+	if !m.SameCurrency(other) {
+		panic(fmt.Sprintf("money: cannot combine %s and %s", m.Currency, other.Currency))
+	}
+	if m.IsSet() {
+		return m.Currency
+	}
+	return other.Currency
+}
+
+// Decimal formats the amount in major units, e.g. "999.99".
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+	digits := strconv.FormatInt(m.Amount, 10)
+	sign := ""
+	if m.Amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	if !m.IsSet() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount": "24.99", "currency": "EUR"} as well as a
+// bare number or decimal string in DefaultCurrency. Numbers are read from
+// their literal text so they are never rounded through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		*m = Money{}
+		return nil
+	}
+	currency := DefaultCurrency()
+	raw := json.RawMessage(text)
+	if strings.HasPrefix(text, "{") {
+		var object struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidMoney, err.Error())
+		}
+		if object.Currency != "" {
+			currency = object.Currency
+		}
+		raw = object.Amount
+	}
+	value := string(raw)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidMoney, err.Error())
+		}
+	}
+	parsed, err := ParseMoney(value, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func divRoundHalfEven(numerator, denominator *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(new(big.Int).Abs(denominator))
+	if half > 0 || (half == 0 && quotient.Bit(0) == 1) {
+		if numerator.Sign()*denominator.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient
+}
+
+// moneyColumns are the float columns that Money fields replaced, with the
+// prefix of the columns holding them now. Only a variant's price could be
+// NULL, meaning no override.
+var moneyColumns = []struct {
+	table, column, prefix string
+	nullable              bool
+}{
+	{"products", "price", "price_", false},
+	{"product_variants", "price", "price_", true},
+	{"orders", "subtotal", "subtotal_", false},
+	{"orders", "discount_total", "discount_total_", false},
+	{"orders", "tax_total", "tax_total_", false},
+	{"orders", "shipping_total", "shipping_total_", false},
+	{"orders", "grand_total", "grand_total_", false},
+	{"order_items", "price", "price_", false},
+	{"order_items", "discount", "discount_", false},
+	{"order_discounts", "amount", "amount_", false},
+	{"coupons", "min_spend", "min_spend_", false},
+	{"payments", "amount", "amount_", false},
+	{"payments", "refunded_amount", "refunded_amount_", false},
+	{"refunds", "amount", "amount_", false},
+	{"refund_items", "amount", "amount_", false},
+}
+
+// migrateMoneyColumns converts amounts stored as floats before Money existed
+// to minor units of DefaultCurrency and drops the float columns. Fixed-amount
+// coupons kept their amount in value and move it to their amount columns.
+// Everything is converted in one transaction, and the server does not start
+// if it fails: the new columns default to 0, so unconverted prices would
+// read as free.
+func migrateMoneyColumns() {
+	currency := DefaultCurrency()
+	scale := int64(math.Pow10(CurrencyExponent(currency)))
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, money := range moneyColumns {
+			if !tx.Migrator().HasColumn(money.table, money.column) {
+				continue
+			}
+			statement := fmt.Sprintf(`UPDATE %s SET %samount = COALESCE(ROUND(CAST(%s AS NUMERIC) * ?), 0), %scurrency = ?`,
+				money.table, money.prefix, money.column, money.prefix)
+			if money.nullable {
+				statement += fmt.Sprintf(" WHERE %s IS NOT NULL", money.column)
+			}
+			if err := tx.Exec(statement, scale, currency).Error; err != nil {
+				return fmt.Errorf("%s.%s: %w", money.table, money.column, err)
+			}
+			if err := tx.Migrator().DropColumn(money.table, money.column); err != nil {
+				return fmt.Errorf("%s.%s: %w", money.table, money.column, err)
+			}
+		}
+		err := tx.Exec(`UPDATE coupons SET amount_amount = ROUND(CAST(value AS NUMERIC) * ?), amount_currency = ?, value = 0
+			WHERE type = 'FIXED_AMOUNT' AND amount_currency = '' AND value > 0`, scale, currency).Error
+		if err != nil {
+			return fmt.Errorf("fixed-amount coupons: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalln("failed to migrate money columns", err)
+	}
+}
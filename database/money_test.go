@@ -0,0 +1,236 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{value: "999.99", currency: "USD", want: Money{Amount: 99999, Currency: "USD"}},
+		{value: "999.9", currency: "usd", want: Money{Amount: 99990, Currency: "USD"}},
+		{value: "12", currency: "EUR", want: Money{Amount: 1200, Currency: "EUR"}},
+		{value: " 0.05 ", currency: "USD", want: Money{Amount: 5, Currency: "USD"}},
+		{value: "-3.50", currency: "USD", want: Money{Amount: -350, Currency: "USD"}},
+		{value: "+3.50", currency: "USD", want: Money{Amount: 350, Currency: "USD"}},
+		{value: "1500", currency: "JPY", want: Money{Amount: 1500, Currency: "JPY"}},
+		{value: "1.234", currency: "KWD", want: Money{Amount: 1234, Currency: "KWD"}},
+		{value: "1.999", currency: "USD", wantErr: true},
+		{value: "1.5", currency: "JPY", wantErr: true},
+		{value: "", currency: "USD", wantErr: true},
+		{value: ".50", currency: "USD", wantErr: true},
+		{value: "1,50", currency: "USD", wantErr: true},
+		{value: "--1", currency: "USD", wantErr: true},
+		{value: "1e3", currency: "USD", wantErr: true},
+		{value: "99999999999999999999", currency: "USD", wantErr: true},
+		{value: "1.00", currency: "DOLLARS", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q, %q) error = %v, want ErrInvalidMoney", tt.value, tt.currency, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoney(%q, %q) = %v, %v, want %v", tt.value, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestDivRoundHalfEven(t *testing.T) {
+	tests := []struct {
+		numerator, denominator int64
+		want                   int64
+	}{
+		{10, 4, 2},   // 2.5 rounds to the even 2
+		{14, 4, 4},   // 3.5 rounds to the even 4
+		{11, 4, 3},   // 2.75
+		{9, 4, 2},    // 2.25
+		{-10, 4, -2}, // -2.5
+		{-14, 4, -4}, // -3.5
+		{-11, 4, -3},
+		{10, -4, -2},
+		{12, 4, 3},
+		{0, 7, 0},
+		{1, 3, 0},
+		{2, 3, 1},
+	}
+	for _, tt := range tests {
+		got := divRoundHalfEven(big.NewInt(tt.numerator), big.NewInt(tt.denominator)).Int64()
+		if got != tt.want {
+			t.Errorf("divRoundHalfEven(%d, %d) = %d, want %d", tt.numerator, tt.denominator, got, tt.want)
+		}
+	}
+}
+
+func TestMulRatioAndPercent(t *testing.T) {
+	price := NewMoney(1999, "USD")
+	if got := price.MulRatio(1, 2); got.Amount != 1000 {
+		t.Errorf("MulRatio(1, 2) of 19.99 = %s, want 10.00", got)
+	}
+	if got := NewMoney(25, "USD").Percent(10); got.Amount != 2 {
+		t.Errorf("10%% of 0.25 = %s, want 0.02", got)
+	}
+	if got := NewMoney(35, "USD").Percent(10); got.Amount != 4 {
+		t.Errorf("10%% of 0.35 = %s, want 0.04", got)
+	}
+	if got := NewMoney(10000, "USD").Percent(12.5); got.Amount != 1250 {
+		t.Errorf("12.5%% of 100.00 = %s, want 12.50", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{amount: 100, weights: []int64{1, 1, 1}, want: []int64{33, 34, 33}},
+		{amount: 1000, weights: []int64{1, 1}, want: []int64{500, 500}},
+		{amount: 1, weights: []int64{1, 1, 1}, want: []int64{0, 1, 0}},
+		{amount: 999, weights: []int64{2000, 1000, 0}, want: []int64{666, 333, 0}},
+		{amount: -100, weights: []int64{1, 1, 1}, want: []int64{-33, -34, -33}},
+		{amount: 100, weights: []int64{0, 0}, want: []int64{0, 0}},
+		{amount: 100, weights: []int64{}, want: []int64{}},
+	}
+	for _, tt := range tests {
+		shares := NewMoney(tt.amount, "USD").Allocate(tt.weights)
+		if len(shares) != len(tt.want) {
+			t.Fatalf("Allocate(%d, %v) returned %d shares, want %d", tt.amount, tt.weights, len(shares), len(tt.want))
+		}
+		var sum int64
+		for i, share := range shares {
+			if share.Amount != tt.want[i] || share.Currency != "USD" {
+				t.Errorf("Allocate(%d, %v)[%d] = %v, want %d USD", tt.amount, tt.weights, i, share, tt.want[i])
+			}
+			sum += share.Amount
+		}
+		var total int64
+		for _, weight := range tt.weights {
+			total += weight
+		}
+		if total != 0 && sum != tt.amount {
+			t.Errorf("Allocate(%d, %v) shares add up to %d", tt.amount, tt.weights, sum)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		from     Money
+		currency string
+		rate     *big.Rat
+		want     Money
+	}{
+		{NewMoney(10000, "USD"), "EUR", big.NewRat(92, 100), NewMoney(9200, "EUR")},
+		{NewMoney(1999, "USD"), "JPY", big.NewRat(1571, 10), NewMoney(3140, "JPY")},
+		{NewMoney(3140, "JPY"), "USD", big.NewRat(10, 1571), NewMoney(1999, "USD")},
+		{NewMoney(100, "USD"), "KWD", big.NewRat(307, 1000), NewMoney(307, "KWD")},
+		{NewMoney(5, "USD"), "EUR", big.NewRat(1, 2), NewMoney(2, "EUR")},   // 2.5 rounds to 2
+		{NewMoney(15, "USD"), "EUR", big.NewRat(1, 10), NewMoney(2, "EUR")}, // 1.5 rounds to 2
+	}
+	for _, tt := range tests {
+		if got := tt.from.Convert(tt.currency, tt.rate); got != tt.want {
+			t.Errorf("%s.Convert(%s, %s) = %s, want %s", tt.from, tt.currency, tt.rate.RatString(), got, tt.want)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(99999, "USD"), "999.99"},
+		{NewMoney(5, "USD"), "0.05"},
+		{NewMoney(-5, "USD"), "-0.05"},
+		{NewMoney(1500, "JPY"), "1500"},
+		{NewMoney(1234, "KWD"), "1.234"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%d %s formats as %q, want %q", tt.money.Amount, tt.money.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestMixedCurrenciesPanic(t *testing.T) {
+	usd, eur := NewMoney(100, "USD"), NewMoney(100, "EUR")
+	operations := map[string]func(){
+		"Add":         func() { usd.Add(eur) },
+		"Sub":         func() { usd.Sub(eur) },
+		"Cmp":         func() { usd.Cmp(eur) },
+		"LessThan":    func() { usd.LessThan(eur) },
+		"GreaterThan": func() { usd.GreaterThan(eur) },
+		"Min":         func() { usd.Min(eur) },
+	}
+	for name, operation := range operations {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s of USD and EUR did not panic", name)
+				}
+			}()
+			operation()
+		}()
+	}
+}
+
+func TestUnsetMoneyTakesOtherCurrency(t *testing.T) {
+	usd := NewMoney(100, "USD")
+	if got := (Money{}).Add(usd); got != usd {
+		t.Errorf("unset + 1.00 USD = %v, want %v", got, usd)
+	}
+	if got := usd.Sub(Money{Amount: 40}); got != NewMoney(60, "USD") {
+		t.Errorf("1.00 USD - unset 0.40 = %v, want 0.60 USD", got)
+	}
+	if !usd.SameCurrency(Money{}) || usd.SameCurrency(NewMoney(1, "EUR")) {
+		t.Error("SameCurrency does not treat an unset amount as matching any currency")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	t.Setenv("DEFAULT_CURRENCY", "USD")
+	tests := []struct {
+		body    string
+		want    Money
+		wantErr bool
+	}{
+		{body: `{"amount": "24.99", "currency": "EUR"}`, want: NewMoney(2499, "EUR")},
+		{body: `{"amount": 0.1}`, want: NewMoney(10, "USD")},
+		{body: `19.99`, want: NewMoney(1999, "USD")},
+		{body: `"1.10"`, want: NewMoney(110, "USD")},
+		{body: `null`, want: Money{}},
+		{body: `0.001`, wantErr: true},
+		{body: `{"amount": "abc"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.body), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("unmarshalling %s gave %v, want an error", tt.body, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("unmarshalling %s = %v, %v, want %v", tt.body, got, err, tt.want)
+		}
+	}
+	encoded, err := json.Marshal(NewMoney(99999, "USD"))
+	if err != nil || string(encoded) != `{"amount":"999.99","currency":"USD"}` {
+		t.Errorf("marshalling 999.99 USD = %s, %v", encoded, err)
+	}
+	if encoded, _ := json.Marshal(Money{}); string(encoded) != "null" {
+		t.Errorf("marshalling unset money = %s, want null", encoded)
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"log"
+)
+
+// migrateOrderTotals fills the totals of orders placed before they were
+// stored, from the prices their items were bought at and their discounts.
+func migrateOrderTotals() {
+	statements := []string{
+		`UPDATE orders SET
+			subtotal_amount = COALESCE((SELECT SUM(order_items.quantity * order_items.price_amount) FROM order_items WHERE order_items.order_id = orders.id), 0),
+			discount_total_amount = COALESCE((SELECT SUM(order_discounts.amount_amount) FROM order_discounts WHERE order_discounts.order_id = orders.id), 0)
+			WHERE subtotal_amount = 0 AND grand_total_amount = 0`,
+		`UPDATE orders SET grand_total_amount = GREATEST(subtotal_amount - discount_total_amount + tax_total_amount + shipping_total_amount, 0)
+			WHERE grand_total_amount = 0 AND subtotal_amount > 0`,
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			log.Println("failed to migrate order totals", err)
+			return
+		}
+	}
+}
+
+// migrateOrderRefundStatus moves the refund state of orders refunded before
+// it was kept apart from their status to refund_status, and gives orders
+// left PARTIALLY_REFUNDED back the status they had before their refund.
+func migrateOrderRefundStatus() {
+	statements := []string{
+		`UPDATE orders SET refund_status = CASE WHEN payments.refunded_amount_amount >= payments.amount_amount THEN 'REFUNDED' ELSE 'PARTIALLY_REFUNDED' END
+			FROM payments WHERE payments.order_id = orders.id AND payments.status = 'PAID' AND payments.refunded_amount_amount > 0 AND orders.refund_status = ''`,
+		`INSERT INTO order_status_histories (order_id, from_status, to_status, changed_by, note, created_at)
+			SELECT orders.id, orders.status, COALESCE((SELECT order_status_histories.from_status FROM order_status_histories
+				WHERE order_status_histories.order_id = orders.id AND order_status_histories.to_status = 'PARTIALLY_REFUNDED' AND order_status_histories.from_status <> 'PARTIALLY_REFUNDED'
+				ORDER BY order_status_histories.id DESC LIMIT 1), 'PAID'), 0, 'refund state moved to refund_status', NOW()
+			FROM orders WHERE orders.status = 'PARTIALLY_REFUNDED'`,
+		`UPDATE orders SET status = COALESCE((SELECT order_status_histories.from_status FROM order_status_histories
+			WHERE order_status_histories.order_id = orders.id AND order_status_histories.to_status = 'PARTIALLY_REFUNDED' AND order_status_histories.from_status <> 'PARTIALLY_REFUNDED'
+			ORDER BY order_status_histories.id DESC LIMIT 1), 'PAID')
+			WHERE orders.status = 'PARTIALLY_REFUNDED'`,
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("failed to migrate order refund status", err)
+	}
+}
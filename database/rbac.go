@@ -0,0 +1,96 @@
+package database
+
+import (
+	"log"
+	"os"
+)
+
+// Built-in roles.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Permissions checked by middleware.RequirePermission.
+const (
+	PermProductsWrite   = "products:write"
+	PermOrdersManage    = "orders:manage"
+	PermOrdersRefund    = "orders:refund"
+	PermOrdersReadAll   = "orders:read_all"
+	PermUsersRead       = "users:read"
+	PermUsersWrite      = "users:write"
+	PermRolesManage     = "roles:manage"
+	PermInventoryManage = "inventory:manage"
+	PermCouponsManage   = "coupons:manage"
+	PermCurrencyManage  = "currencies:manage"
+	PermTaxesManage     = "taxes:manage"
+	PermShippingManage  = "shipping:manage"
+	PermReturnsManage   = "returns:manage"
+)
+
+var defaultPermissions = []Permission{
+	{Name: PermProductsWrite, Description: "Create, update and delete products and categories"},
+	{Name: PermOrdersManage, Description: "Ship, deliver and reject any order"},
+	{Name: PermOrdersRefund, Description: "Refund paid orders"},
+	{Name: PermOrdersReadAll, Description: "View and pay any user's orders"},
+	{Name: PermUsersRead, Description: "List all users"},
+	{Name: PermUsersWrite, Description: "Update any user"},
+	{Name: PermRolesManage, Description: "Manage roles and assign them to users"},
+	{Name: PermInventoryManage, Description: "Adjust stock and view the stock ledger and low-stock alerts"},
+	{Name: PermCouponsManage, Description: "Create, update and delete coupons"},
+	{Name: PermCurrencyManage, Description: "Set and import exchange rates"},
+	{Name: PermTaxesManage, Description: "Manage tax classes and rates"},
+	{Name: PermShippingManage, Description: "Manage shipping methods and their rates"},
+	{Name: PermReturnsManage, Description: "Approve, reject and receive return requests"},
+}
+
+// SeedRBAC creates the built-in permissions and roles, moves users from the
+// legacy users.role column onto the user_roles table, and grants the admin
+// role to ADMIN_EMAIL when set. It is safe to run on every start.
+func SeedRBAC() {
+	for i := range defaultPermissions {
+		if err := DB.Where(Permission{Name: defaultPermissions[i].Name}).Attrs(defaultPermissions[i]).FirstOrCreate(&defaultPermissions[i]).Error; err != nil {
+			log.Println("failed to seed permission", defaultPermissions[i].Name, err)
+			return
+		}
+	}
+	admin := Role{Name: RoleAdmin, Description: "Full access to the store"}
+	user := Role{Name: RoleUser, Description: "Regular customer"}
+	for _, role := range []*Role{&admin, &user} {
+		if err := DB.Where(Role{Name: role.Name}).Attrs(*role).FirstOrCreate(role).Error; err != nil {
+			log.Println("failed to seed role", role.Name, err)
+			return
+		}
+	}
+	if err := DB.Model(&admin).Association("Permissions").Append(defaultPermissions); err != nil {
+		log.Println("failed to grant admin permissions", err)
+	}
+
+	if DB.Migrator().HasColumn(&User{}, "role") {
+		// Only a role of exactly "user" used to mean a customer; anything
+		// else, even an unknown or empty role, was treated as an admin.
+		err := DB.Exec(`INSERT INTO user_roles (user_id, role_id)
+			SELECT users.id, CASE WHEN users.role IS DISTINCT FROM 'user' THEN ? ELSE ? END FROM users
+			WHERE NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)`, admin.ID, user.ID).Error
+		if err != nil {
+			log.Println("failed to migrate legacy user roles", err)
+		} else if err := DB.Migrator().DropColumn(&User{}, "role"); err != nil {
+			log.Println("failed to drop legacy role column", err)
+		}
+	}
+	err := DB.Exec(`INSERT INTO user_roles (user_id, role_id)
+		SELECT users.id, ? FROM users
+		WHERE NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)`, user.ID).Error
+	if err != nil {
+		log.Println("failed to assign default role", err)
+	}
+
+	if email := os.Getenv("ADMIN_EMAIL"); email != "" {
+		var account User
+		if err := DB.Where("email = ?", email).First(&account).Error; err == nil {
+			if err := DB.Model(&account).Association("Roles").Append(&admin); err != nil {
+				log.Println("failed to grant admin role to", email, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package database
+
+import "log"
+
+// migrateProductSearch adds the full-text search column and its GIN index.
+// The column is generated by PostgreSQL so it never needs to be written by
+// the application; other dialects fall back to LIKE queries.
+func migrateProductSearch() {
+	if DB.Dialector.Name() != "postgres" {
+		return
+	}
+	statements := []string{
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce(description, '')), 'B')
+			) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			log.Println("failed to migrate product search", err)
+			return
+		}
+	}
+}
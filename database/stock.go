@@ -0,0 +1,36 @@
+package database
+
+import "log"
+
+// Types of database.StockMovement. Every type except reservation releases
+// changes on-hand stock by its quantity, so StockQty always equals the sum of
+// those movements.
+const (
+	StockMovementReceipt            = "RECEIPT"
+	StockMovementSale               = "SALE"
+	StockMovementReturn             = "RETURN"
+	StockMovementAdjustment         = "ADJUSTMENT"
+	StockMovementReservationRelease = "RESERVATION_RELEASE"
+)
+
+// SeedStockLedger records an opening balance for every product and variant
+// that has stock but no movements yet, e.g. ones created before the ledger
+// existed. It is safe to run on every start.
+func SeedStockLedger() {
+	statements := []string{
+		`INSERT INTO stock_movements (product_id, variant_id, type, quantity, stock_after, reason, reference, actor_id, created_at)
+			SELECT products.id, 0, ?, products.stock_qty, products.stock_qty, 'opening balance', '', 0, NOW() FROM products
+			WHERE products.stock_qty <> 0 AND NOT EXISTS (
+				SELECT 1 FROM stock_movements WHERE stock_movements.product_id = products.id AND stock_movements.variant_id = 0)`,
+		`INSERT INTO stock_movements (product_id, variant_id, type, quantity, stock_after, reason, reference, actor_id, created_at)
+			SELECT product_variants.product_id, product_variants.id, ?, product_variants.stock_qty, product_variants.stock_qty, 'opening balance', '', 0, NOW() FROM product_variants
+			WHERE product_variants.stock_qty <> 0 AND NOT EXISTS (
+				SELECT 1 FROM stock_movements WHERE stock_movements.variant_id = product_variants.id)`,
+	}
+	for _, statement := range statements {
+		if err := DB.Exec(statement, StockMovementReceipt).Error; err != nil {
+			log.Println("failed to seed stock ledger", err)
+			return
+		}
+	}
+}
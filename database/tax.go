@@ -0,0 +1,20 @@
+package database
+
+import "log"
+
+// TaxClassStandard is the tax class of products that have none.
+const TaxClassStandard = "standard"
+
+// migrateTaxes creates the standard tax class and gives the tax of order
+// items placed before taxes were charged the currency of their price.
+func migrateTaxes() {
+	standard := TaxClass{Name: TaxClassStandard, Description: "Products without a tax class"}
+	if err := DB.Where(TaxClass{Name: TaxClassStandard}).Attrs(standard).FirstOrCreate(&standard).Error; err != nil {
+		log.Println("failed to seed the standard tax class", err)
+		return
+	}
+	err := DB.Exec(`UPDATE order_items SET tax_currency = price_currency, tax_class = ? WHERE tax_currency = ''`, TaxClassStandard).Error
+	if err != nil {
+		log.Println("failed to migrate order item taxes", err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/routes"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -39,6 +40,10 @@ func main() {
 	}
 	r := gin.Default()
 	database.Connect()
+	utils.StartReservationSweeper()
+	utils.StartStockReconciler()
+	utils.StartIdempotencyKeySweeper()
+	utils.StartGuestCartSweeper()
 	
 	// Swagger documentation route
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
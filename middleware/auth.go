@@ -11,20 +11,16 @@ import (
 func Authentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		fmt.Println("Auth header:", authHeader)
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header not provided"})
 			return
 		}
 		splitToken := strings.Split(authHeader, "Bearer ")
-		fmt.Println("Split token:", splitToken)
 		if len(splitToken) != 2 {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is invalid"})
 			return
 		}
 		tokenString := splitToken[1]
-		fmt.Println("Token string length:", len(tokenString))
-		fmt.Println("Token string:", tokenString)
 		
 		// Trim any whitespace from the token
 		tokenString = strings.TrimSpace(tokenString)
@@ -44,13 +40,37 @@ func Authentication() gin.HandlerFunc {
 			}
 		}
 		
-		userId, err := utils.ParseToken(tokenString)
-		fmt.Println("userId", userId)
+		claims, err := utils.ParseToken(tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-		c.Set("userId", userId)
+		revoked, err := utils.IsSessionRevoked(claims.SessionID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "error while checking the session"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+			return
+		}
+		c.Set("userId", claims.UserID)
+		c.Set("sessionId", claims.SessionID)
+		c.Set("roles", claims.Roles)
 		c.Next()
 	}
 }
+
+// OptionalAuthentication authenticates requests that carry an Authorization
+// header like Authentication does, and lets the others through as guests
+// without a userId.
+func OptionalAuthentication() gin.HandlerFunc {
+	authenticate := Authentication()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// CartTokenCookie is the cookie a guest cart token is kept in.
+const CartTokenCookie = "cart_token"
+
+// CartToken returns the guest cart token sent with the request, from the
+// X-Cart-Token header or the cart_token cookie.
+func CartToken(c *gin.Context) string {
+	if token := strings.TrimSpace(c.GetHeader("X-Cart-Token")); token != "" {
+		return token
+	}
+	token, _ := c.Cookie(CartTokenCookie)
+	return token
+}
+
+// SetCartToken hands a guest cart token to the client in the X-Cart-Token
+// header and the cart_token cookie.
+func SetCartToken(c *gin.Context, token string) {
+	c.Header("X-Cart-Token", token)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(CartTokenCookie, token, int(utils.CartTokenTTL().Seconds()), "/", "", c.Request.TLS != nil, true)
+}
+
+// ClearCartToken deletes the cart_token cookie once the guest cart is gone.
+func ClearCartToken(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(CartTokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// Currency picks the currency prices are shown and charged in from the
+// X-Currency header or the currency query parameter, falling back to the
+// base currency. Currencies without an exchange rate are rejected.
+func Currency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currency := strings.ToUpper(strings.TrimSpace(c.GetHeader("X-Currency")))
+		if currency == "" {
+			currency = strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+		}
+		if currency == "" {
+			currency = database.DefaultCurrency()
+		}
+		supported, err := utils.IsSupportedCurrency(database.DB, currency)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !supported {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unsupported currency " + currency})
+			return
+		}
+		c.Set("currency", currency)
+		c.Next()
+	}
+}
+
+// RequestCurrency returns the currency chosen for the request.
+func RequestCurrency(c *gin.Context) string {
+	if currency := c.GetString("currency"); currency != "" {
+		return currency
+	}
+	return database.DefaultCurrency()
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder keeps a copy of the response body written through it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Idempotency makes requests sent with an Idempotency-Key header safe to
+// retry: the first response for a caller's key is stored, and a retry with
+// the same key and body gets it back without running the handler again.
+// Server errors are not stored, so they can be retried. It must run after
+// Authentication.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key cannot be longer than 255 characters"})
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "error while reading the request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, replay, err := utils.BeginIdempotentRequest(c.GetUint("userId"), key, requestFingerprint(c, body))
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.ErrIdempotencyKeyInFlight):
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, utils.ErrIdempotencyKeyReused):
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.ResponseCode, record.ContentType, []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		stopHolding := utils.HoldIdempotentRequest(record)
+		defer stopHolding()
+		completed := false
+		defer func() {
+			// Let a retry run the request again when the handler panicked.
+			if !completed {
+				releaseIdempotencyKey(record)
+			}
+		}()
+		c.Next()
+		completed = true
+		if recorder.Status() >= http.StatusInternalServerError {
+			releaseIdempotencyKey(record)
+			return
+		}
+		if err := utils.CompleteIdempotentRequest(record, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("failed to store the response for idempotency key %d, a retry after IDEMPOTENCY_LOCK_TIMEOUT will run the request again: %v", record.ID, err)
+		}
+	}
+}
+
+func releaseIdempotencyKey(record database.IdempotencyKey) {
+	if err := utils.ReleaseIdempotentRequest(record); err != nil {
+		log.Printf("failed to release idempotency key %d, retries are refused until IDEMPOTENCY_LOCK_TIMEOUT passes: %v", record.ID, err)
+	}
+}
+
+// requestFingerprint identifies what a request asks for, so a key reused for
+// a different request can be told apart from a retry.
+func requestFingerprint(c *gin.Context, body []byte) string {
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		body = compact.Bytes()
+	}
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequirePermission only lets the request through when one of the roles in
+// the caller's access token grants the permission. It must run after
+// Authentication.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := utils.RolesHavePermission(Roles(c), permission)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "you are not authorized to perform this action"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission is the in-handler form of RequirePermission, for endpoints
+// where the permission only widens access (e.g. viewing other users' orders).
+func HasPermission(c *gin.Context, permission string) bool {
+	allowed, err := utils.RolesHavePermission(Roles(c), permission)
+	return err == nil && allowed
+}
+
+// Roles returns the roles carried by the caller's access token.
+func Roles(c *gin.Context) []string {
+	roles, _ := c.Get("roles")
+	names, _ := roles.([]string)
+	return names
+}
@@ -1,59 +1,220 @@
 package routes
 
 import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/addresses"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/carts"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/categories"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/coupons"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/currencies"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/inventory"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/orders"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/products"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/returns"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/roles"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/shipping"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/taxes"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/api/users"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/middleware"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/utils"
 	"github.com/gin-gonic/gin"
+	"log"
 )
 
 func SetupRoutes(r *gin.Engine) *gin.Engine {
 	r.POST("/users/login", users.LoginUser)
 	r.POST("/users/register", users.RegisterUser)
+	r.POST("/users/token/refresh", users.RefreshToken)
+	setupUploadRoutes(r)
 	protected := r.Group("/")
-	protected.Use(middleware.Authentication())
+	protected.Use(middleware.Authentication(), middleware.Currency())
 	{
 		setupProductRoutes(protected)
+		setupCategoryRoutes(protected)
 		setupOrderRoutes(protected)
 		setupUserRoutes(protected)
-		setupCartRoutes(protected)
+		setupRoleRoutes(protected)
+		setupInventoryRoutes(protected)
+		setupCouponRoutes(protected)
+		setupCurrencyRoutes(protected)
+		setupTaxRoutes(protected)
+		setupShippingRoutes(protected)
+		setupReturnRoutes(protected)
+	}
+	// Carts also work for guests, identified by a cart token.
+	guest := r.Group("/")
+	guest.Use(middleware.OptionalAuthentication(), middleware.Currency())
+	{
+		setupCartRoutes(guest)
 	}
 	return r
 }
 func setupProductRoutes(rg *gin.RouterGroup) {
 	productRoutes := rg.Group("/products")
 	{
-		productRoutes.POST("/create", products.CreateProduct)
+		productRoutes.POST("/create", middleware.RequirePermission(database.PermProductsWrite), products.CreateProduct)
 		productRoutes.GET("/all", products.GetAllProducts)
+		productRoutes.GET("/search", products.SearchProducts)
 		productRoutes.GET("/:id", products.GetOneProduct)
-		productRoutes.DELETE("/delete/:id", products.DeleteProduct)
-		productRoutes.PUT("/update/:id", products.UpdateProduct)
+		productRoutes.DELETE("/delete/:id", middleware.RequirePermission(database.PermProductsWrite), products.DeleteProduct)
+		productRoutes.PUT("/update/:id", middleware.RequirePermission(database.PermProductsWrite), products.UpdateProduct)
+		productRoutes.PUT("/:id/categories", middleware.RequirePermission(database.PermProductsWrite), products.SetProductCategories)
+		productRoutes.GET("/:id/variants", products.GetProductVariants)
+		productRoutes.POST("/:id/variants", middleware.RequirePermission(database.PermProductsWrite), products.CreateProductVariant)
+		productRoutes.PUT("/:id/variants/:variantId", middleware.RequirePermission(database.PermProductsWrite), products.UpdateProductVariant)
+		productRoutes.DELETE("/:id/variants/:variantId", middleware.RequirePermission(database.PermProductsWrite), products.DeleteProductVariant)
+		productRoutes.GET("/:id/prices", middleware.RequirePermission(database.PermProductsWrite), products.GetProductPrices)
+		productRoutes.PUT("/:id/prices", middleware.RequirePermission(database.PermProductsWrite), products.SetProductPrice)
+		productRoutes.DELETE("/:id/prices/:priceId", middleware.RequirePermission(database.PermProductsWrite), products.DeleteProductPrice)
+		productRoutes.GET("/:id/images", products.GetProductImages)
+		productRoutes.POST("/:id/images", middleware.RequirePermission(database.PermProductsWrite), products.UploadProductImages)
+		productRoutes.PUT("/:id/images/order", middleware.RequirePermission(database.PermProductsWrite), products.ReorderProductImages)
+		productRoutes.PUT("/:id/images/:imageId/primary", middleware.RequirePermission(database.PermProductsWrite), products.SetPrimaryProductImage)
+		productRoutes.DELETE("/:id/images/:imageId", middleware.RequirePermission(database.PermProductsWrite), products.DeleteProductImage)
+	}
+}
+func setupCategoryRoutes(rg *gin.RouterGroup) {
+	categoryRoutes := rg.Group("/categories")
+	{
+		categoryRoutes.GET("/all", categories.GetAllCategories)
+		categoryRoutes.GET("/tree", categories.GetCategoryTree)
+		categoryRoutes.GET("/:id", categories.GetCategory)
+		categoryRoutes.POST("", middleware.RequirePermission(database.PermProductsWrite), categories.CreateCategory)
+		categoryRoutes.PUT("/:id", middleware.RequirePermission(database.PermProductsWrite), categories.UpdateCategory)
+		categoryRoutes.DELETE("/:id", middleware.RequirePermission(database.PermProductsWrite), categories.DeleteCategory)
 	}
 }
 func setupUserRoutes(rg *gin.RouterGroup) {
 	userRoutes := rg.Group("/users")
 	{
-		userRoutes.GET("/all", users.GetAllUsers)
+		userRoutes.GET("/all", middleware.RequirePermission(database.PermUsersRead), users.GetAllUsers)
 		userRoutes.GET("/mine", users.GetYourAccount)
-		userRoutes.PUT("/update/user/:id", users.UpdateUser)
+		userRoutes.PUT("/update/user/:id", middleware.RequirePermission(database.PermUsersWrite), users.UpdateUser)
 		userRoutes.DELETE("/delete/myAccount", users.DeleteYourAccount)
+		userRoutes.POST("/logout", users.Logout)
+		userRoutes.GET("/addresses", addresses.GetMyAddresses)
+		userRoutes.POST("/addresses", addresses.CreateAddress)
+		userRoutes.GET("/addresses/:id", addresses.GetMyAddress)
+		userRoutes.PUT("/addresses/:id", addresses.UpdateAddress)
+		userRoutes.DELETE("/addresses/:id", addresses.DeleteAddress)
+		userRoutes.POST("/:id/roles", middleware.RequirePermission(database.PermRolesManage), roles.AssignRole)
+		userRoutes.DELETE("/:id/roles/:role", middleware.RequirePermission(database.PermRolesManage), roles.RemoveRole)
 	}
 }
 func setupOrderRoutes(rg *gin.RouterGroup) {
 	orderRoutes := rg.Group("/orders")
 	{
-		orderRoutes.POST("/place-order", orders.PlaceOrder)
-		orderRoutes.PUT("/deliver", orders.Deliver)
-		orderRoutes.DELETE("/reject", orders.RejectOrder)
-		orderRoutes.POST("/pay", orders.PayOrder)
+		orderRoutes.POST("/place-order", middleware.Idempotency(), orders.PlaceOrder)
+		orderRoutes.PUT("/ship", middleware.RequirePermission(database.PermOrdersManage), orders.Ship)
+		orderRoutes.PUT("/deliver", middleware.RequirePermission(database.PermOrdersManage), orders.Deliver)
+		orderRoutes.DELETE("/reject", middleware.RequirePermission(database.PermOrdersManage), orders.RejectOrder)
+		orderRoutes.POST("/pay", middleware.Idempotency(), orders.PayOrder)
+		orderRoutes.POST("/pay/confirm", orders.ConfirmPayment)
+		orderRoutes.POST("/refund", middleware.RequirePermission(database.PermOrdersRefund), orders.RefundOrder)
+		orderRoutes.POST("/refund/partial", middleware.RequirePermission(database.PermOrdersRefund), orders.PartialRefund)
+		orderRoutes.GET("/mine", orders.GetMyOrders)
+		orderRoutes.GET("/:id", orders.GetOrder)
+		orderRoutes.GET("/:id/history", orders.GetOrderHistory)
+		orderRoutes.GET("/:id/invoice", orders.GetOrderInvoice)
+		orderRoutes.POST("/:id/cancel", orders.CancelOrder)
+		orderRoutes.GET("/:id/shipments", orders.GetOrderShipments)
+		orderRoutes.POST("/:id/shipments", middleware.RequirePermission(database.PermOrdersManage), orders.CreateShipment)
+		orderRoutes.PUT("/:id/shipments/:shipmentId/deliver", middleware.RequirePermission(database.PermOrdersManage), orders.DeliverShipment)
 	}
 }
 func setupCartRoutes(rg *gin.RouterGroup) {
 	cartRoutes := rg.Group("/carts")
 	{
+		cartRoutes.GET("/mine", carts.GetMyCart)
 		cartRoutes.POST("/add", carts.AddItemToCart)
 		cartRoutes.DELETE("/remove", carts.RemoveItemToCart)
+		cartRoutes.POST("/coupon", carts.ApplyCoupon)
+		cartRoutes.DELETE("/coupon", carts.RemoveCoupon)
+		cartRoutes.GET("/shipping-options", carts.GetShippingOptions)
+	}
+}
+func setupRoleRoutes(rg *gin.RouterGroup) {
+	roleRoutes := rg.Group("/roles")
+	roleRoutes.Use(middleware.RequirePermission(database.PermRolesManage))
+	{
+		roleRoutes.GET("", roles.GetAllRoles)
+		roleRoutes.POST("", roles.CreateRole)
+		roleRoutes.GET("/permissions", roles.GetAllPermissions)
+		roleRoutes.PUT("/:id/permissions", roles.UpdateRolePermissions)
+	}
+}
+func setupInventoryRoutes(rg *gin.RouterGroup) {
+	inventoryRoutes := rg.Group("/inventory")
+	inventoryRoutes.Use(middleware.RequirePermission(database.PermInventoryManage))
+	{
+		inventoryRoutes.POST("/adjustments", inventory.AdjustStock)
+		inventoryRoutes.GET("/movements", inventory.GetStockMovements)
+		inventoryRoutes.GET("/alerts", inventory.GetStockAlerts)
+		inventoryRoutes.POST("/reconcile", inventory.ReconcileStock)
+	}
+}
+func setupCouponRoutes(rg *gin.RouterGroup) {
+	couponRoutes := rg.Group("/coupons")
+	couponRoutes.Use(middleware.RequirePermission(database.PermCouponsManage))
+	{
+		couponRoutes.GET("", coupons.GetAllCoupons)
+		couponRoutes.POST("", coupons.CreateCoupon)
+		couponRoutes.GET("/:id", coupons.GetCoupon)
+		couponRoutes.PUT("/:id", coupons.UpdateCoupon)
+		couponRoutes.DELETE("/:id", coupons.DeleteCoupon)
+	}
+}
+func setupCurrencyRoutes(rg *gin.RouterGroup) {
+	currencyRoutes := rg.Group("/currencies")
+	{
+		currencyRoutes.GET("", currencies.GetCurrencies)
+		currencyRoutes.PUT("/rates/:currency", middleware.RequirePermission(database.PermCurrencyManage), currencies.SetRate)
+		currencyRoutes.DELETE("/rates/:currency", middleware.RequirePermission(database.PermCurrencyManage), currencies.DeleteRate)
+		currencyRoutes.POST("/rates/import", middleware.RequirePermission(database.PermCurrencyManage), currencies.ImportRates)
+	}
+}
+func setupTaxRoutes(rg *gin.RouterGroup) {
+	taxRoutes := rg.Group("/taxes")
+	taxRoutes.Use(middleware.RequirePermission(database.PermTaxesManage))
+	{
+		taxRoutes.GET("/classes", taxes.GetTaxClasses)
+		taxRoutes.POST("/classes", taxes.CreateTaxClass)
+		taxRoutes.PUT("/classes/:id", taxes.UpdateTaxClass)
+		taxRoutes.DELETE("/classes/:id", taxes.DeleteTaxClass)
+		taxRoutes.GET("/rates", taxes.GetTaxRates)
+		taxRoutes.POST("/rates", taxes.CreateTaxRate)
+		taxRoutes.PUT("/rates/:id", taxes.UpdateTaxRate)
+		taxRoutes.DELETE("/rates/:id", taxes.DeleteTaxRate)
+	}
+}
+func setupShippingRoutes(rg *gin.RouterGroup) {
+	shippingRoutes := rg.Group("/shipping")
+	{
+		shippingRoutes.GET("/methods", shipping.GetShippingMethods)
+		shippingRoutes.POST("/methods", middleware.RequirePermission(database.PermShippingManage), shipping.CreateShippingMethod)
+		shippingRoutes.PUT("/methods/:id", middleware.RequirePermission(database.PermShippingManage), shipping.UpdateShippingMethod)
+		shippingRoutes.DELETE("/methods/:id", middleware.RequirePermission(database.PermShippingManage), shipping.DeleteShippingMethod)
+	}
+}
+func setupReturnRoutes(rg *gin.RouterGroup) {
+	returnRoutes := rg.Group("/returns")
+	{
+		returnRoutes.POST("", returns.CreateReturn)
+		returnRoutes.GET("/mine", returns.GetMyReturns)
+		returnRoutes.GET("", middleware.RequirePermission(database.PermReturnsManage), returns.GetReturns)
+		returnRoutes.GET("/:id", returns.GetReturn)
+		returnRoutes.PUT("/:id/approve", middleware.RequirePermission(database.PermReturnsManage), returns.ApproveReturn)
+		returnRoutes.PUT("/:id/reject", middleware.RequirePermission(database.PermReturnsManage), returns.RejectReturn)
+		returnRoutes.PUT("/:id/receive", middleware.RequirePermission(database.PermReturnsManage), returns.ReceiveReturn)
+	}
+}
+func setupUploadRoutes(r *gin.Engine) {
+	storage, err := utils.ActiveStorage()
+	if err != nil {
+		log.Println("uploads are not served:", err)
+		return
+	}
+	if local, ok := storage.(*utils.LocalStorage); ok {
+		r.Static(local.BaseURL, local.Root)
 	}
 }
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidAddress       = errors.New("invalid address")
+	ErrAddressNotFound      = errors.New("address not found")
+	ErrShippingAddressUnset = errors.New("a shipping address is required, add one to the address book or pass shipping_address_id")
+)
+
+// addressFormat is how addresses of a country are written: the pattern of
+// their postal code and whether they need a region (state, province).
+type addressFormat struct {
+	postalCode     *regexp.Regexp
+	postalExample  string
+	regionRequired bool
+}
+
+// addressFormats covers the countries with a well-known postal code format.
+// Addresses elsewhere only need a name, street, city and country.
+var addressFormats = map[string]addressFormat{
+	"US": {regexp.MustCompile(`^\d{5}(-\d{4})?$`), "94105 or 94105-1234", true},
+	"CA": {regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`), "K1A 0B1", true},
+	"AU": {regexp.MustCompile(`^\d{4}$`), "2000", true},
+	"GB": {regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`), "SW1A 1AA", false},
+	"DE": {regexp.MustCompile(`^\d{5}$`), "10115", false},
+	"FR": {regexp.MustCompile(`^\d{5}$`), "75001", false},
+	"ES": {regexp.MustCompile(`^\d{5}$`), "28001", false},
+	"IT": {regexp.MustCompile(`^\d{5}$`), "00118", false},
+	"NL": {regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`), "1012 AB", false},
+	"BE": {regexp.MustCompile(`^\d{4}$`), "1000", false},
+	"JP": {regexp.MustCompile(`^\d{3}-?\d{4}$`), "100-0001", false},
+	"IN": {regexp.MustCompile(`^\d{6}$`), "110001", false},
+	"BR": {regexp.MustCompile(`^\d{5}-?\d{3}$`), "01001-000", false},
+	"KE": {regexp.MustCompile(`^\d{5}$`), "00100", false},
+}
+
+// NormalizeAddress trims an address, upper-cases its country, region and
+// postal code and checks it against its country's format.
+func NormalizeAddress(address *database.PostalAddress) error {
+	address.Name = strings.TrimSpace(address.Name)
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.Line2 = strings.TrimSpace(address.Line2)
+	address.City = strings.TrimSpace(address.City)
+	address.Region = strings.ToUpper(strings.TrimSpace(address.Region))
+	address.PostalCode = strings.ToUpper(strings.TrimSpace(address.PostalCode))
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	address.Phone = strings.TrimSpace(address.Phone)
+	if address.Name == "" || address.Line1 == "" || address.City == "" {
+		return fmt.Errorf("%w: name, line1 and city are required", ErrInvalidAddress)
+	}
+	if !ValidCountryCode(address.Country) {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, ErrInvalidTaxZone.Error())
+	}
+	format, ok := addressFormats[address.Country]
+	if !ok {
+		return nil
+	}
+	if format.regionRequired && address.Region == "" {
+		return fmt.Errorf("%w: region is required in %s", ErrInvalidAddress, address.Country)
+	}
+	if !format.postalCode.MatchString(address.PostalCode) {
+		return fmt.Errorf("%w: postal code in %s must look like %s", ErrInvalidAddress, address.Country, format.postalExample)
+	}
+	return nil
+}
+
+// ClearDefaultAddresses unsets the user's default shipping and/or billing
+// address, except on keepID, before another address takes the role.
+func ClearDefaultAddresses(tx *gorm.DB, userID uint, keepID uint, shipping bool, billing bool) error {
+	if shipping {
+		if err := tx.Model(&database.Address{}).Where("user_id = ? AND id <> ?", userID, keepID).Update("is_default_shipping", false).Error; err != nil {
+			return fmt.Errorf("failed to update default addresses")
+		}
+	}
+	if billing {
+		if err := tx.Model(&database.Address{}).Where("user_id = ? AND id <> ?", userID, keepID).Update("is_default_billing", false).Error; err != nil {
+			return fmt.Errorf("failed to update default addresses")
+		}
+	}
+	return nil
+}
+
+// ResolveOrderAddresses loads the user's addresses an order ships and bills
+// to. A zero id stands for the default address; without a default billing
+// address the order is billed to its shipping address.
+func ResolveOrderAddresses(db *gorm.DB, userID uint, shippingID uint, billingID uint) (database.Address, database.Address, error) {
+	shipping, err := userAddress(db, userID, shippingID, "is_default_shipping")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if shippingID == 0 {
+				return shipping, shipping, ErrShippingAddressUnset
+			}
+			return shipping, shipping, fmt.Errorf("%w: shipping address %d", ErrAddressNotFound, shippingID)
+		}
+		return shipping, shipping, err
+	}
+	billing, err := userAddress(db, userID, billingID, "is_default_billing")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if billingID == 0 {
+				return shipping, shipping, nil
+			}
+			return shipping, billing, fmt.Errorf("%w: billing address %d", ErrAddressNotFound, billingID)
+		}
+		return shipping, billing, err
+	}
+	return shipping, billing, nil
+}
+
+func userAddress(db *gorm.DB, userID uint, addressID uint, defaultColumn string) (database.Address, error) {
+	var address database.Address
+	query := db.Where("user_id = ?", userID)
+	if addressID != 0 {
+		query = query.Where("id = ?", addressID)
+	} else {
+		query = query.Where(defaultColumn+" = ?", true)
+	}
+	err := query.First(&address).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return address, fmt.Errorf("failed to load addresses")
+	}
+	return address, err
+}
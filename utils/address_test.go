@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"errors"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"testing"
+)
+
+func TestNormalizeAddressPostalFormats(t *testing.T) {
+	tests := []struct {
+		country    string
+		region     string
+		postalCode string
+		wantErr    bool
+	}{
+		{country: "US", region: "CA", postalCode: "94105"},
+		{country: "US", region: "CA", postalCode: "94105-1234"},
+		{country: "US", region: "CA", postalCode: "9410", wantErr: true},
+		{country: "US", region: "CA", postalCode: "94105-12", wantErr: true},
+		{country: "US", postalCode: "94105", wantErr: true},
+		{country: "CA", region: "ON", postalCode: "k1a 0b1"},
+		{country: "CA", region: "ON", postalCode: "K1A0B1"},
+		{country: "CA", region: "ON", postalCode: "11A 0B1", wantErr: true},
+		{country: "AU", region: "NSW", postalCode: "2000"},
+		{country: "AU", postalCode: "2000", wantErr: true},
+		{country: "GB", postalCode: "SW1A 1AA"},
+		{country: "GB", postalCode: "m1 1ae"},
+		{country: "GB", postalCode: "SW1A", wantErr: true},
+		{country: "DE", postalCode: "10115"},
+		{country: "DE", postalCode: "1011", wantErr: true},
+		{country: "NL", postalCode: "1012 ab"},
+		{country: "NL", postalCode: "1012", wantErr: true},
+		{country: "JP", postalCode: "100-0001"},
+		{country: "JP", postalCode: "1000001"},
+		{country: "BR", postalCode: "01001-000"},
+		{country: "IN", postalCode: "11000", wantErr: true},
+		{country: "KE", postalCode: "00100"},
+		// Countries without a known format take any postal code.
+		{country: "RW", postalCode: ""},
+		{country: "NZ", postalCode: "anything"},
+	}
+	for _, tt := range tests {
+		address := database.PostalAddress{Name: "John Doe", Line1: "1 Market Street", City: "City", Region: tt.region, PostalCode: tt.postalCode, Country: tt.country}
+		err := NormalizeAddress(&address)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAddress) {
+				t.Errorf("NormalizeAddress(%s %q %q) error = %v, want ErrInvalidAddress", tt.country, tt.region, tt.postalCode, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NormalizeAddress(%s %q %q) error = %v", tt.country, tt.region, tt.postalCode, err)
+		}
+	}
+}
+
+func TestNormalizeAddressTrimsAndUpperCases(t *testing.T) {
+	address := database.PostalAddress{
+		Name:       "  John Doe ",
+		Line1:      " 1 Market Street",
+		Line2:      " ",
+		City:       "San Francisco ",
+		Region:     " ca",
+		PostalCode: " 94105 ",
+		Country:    "us ",
+		Phone:      " +14155550100",
+	}
+	if err := NormalizeAddress(&address); err != nil {
+		t.Fatalf("NormalizeAddress error = %v", err)
+	}
+	want := database.PostalAddress{
+		Name:       "John Doe",
+		Line1:      "1 Market Street",
+		City:       "San Francisco",
+		Region:     "CA",
+		PostalCode: "94105",
+		Country:    "US",
+		Phone:      "+14155550100",
+	}
+	if address != want {
+		t.Errorf("NormalizeAddress = %+v, want %+v", address, want)
+	}
+}
+
+func TestNormalizeAddressRequiredFields(t *testing.T) {
+	tests := []database.PostalAddress{
+		{Line1: "1 Market Street", City: "Kigali", Country: "RW"},
+		{Name: "John Doe", Line1: "  ", City: "Kigali", Country: "RW"},
+		{Name: "John Doe", Line1: "1 Market Street", Country: "RW"},
+		{Name: "John Doe", Line1: "1 Market Street", City: "Kigali"},
+		{Name: "John Doe", Line1: "1 Market Street", City: "Kigali", Country: "RWA"},
+	}
+	for _, address := range tests {
+		if err := NormalizeAddress(&address); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("NormalizeAddress(%+v) error = %v, want ErrInvalidAddress", address, err)
+		}
+	}
+}
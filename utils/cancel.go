@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+var ErrOrderNotCancellable = errors.New("order can no longer be cancelled")
+
+// CancelOrder cancels an order that is awaiting payment, or paid but not
+// shipped, and puts its items back in stock. A paid order is refunded in
+// full through the payment gateway first; the refund is returned with it.
+// The order is kept, CANCELLED, with the reason. Whether the order can be
+// cancelled is checked again with it locked, so a payment or shipment made
+// meanwhile is never lost.
+func CancelOrder(orderID uint, actorID uint, reason string) (*database.Refund, error) {
+	reason = strings.TrimSpace(reason)
+	var order database.Order
+	if err := database.DB.First(&order, orderID).Error; err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if err := checkCancellable(database.DB, order); err != nil {
+		return nil, err
+	}
+	status := order.Status
+	if status == OrderStatusPaid {
+		refund, err := refundOrder(order.ID, actorID, reason, refundRequest{
+			full:   true,
+			cancel: true,
+			check:  checkCancellable,
+			record: func(tx *gorm.DB, order *database.Order, _ database.Refund) error {
+				return recordCancellation(tx, order, reason)
+			},
+		})
+		// An order paid nothing for, e.g. with a 100% discount, is only
+		// restocked.
+		if !errors.Is(err, ErrNothingToRefund) && !errors.Is(err, ErrOrderNotRefundable) {
+			if err != nil {
+				return nil, err
+			}
+			return &refund, nil
+		}
+	}
+	return nil, database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, order.ID).Error; err != nil {
+			return fmt.Errorf("failed to load order")
+		}
+		if order.Status != status {
+			return fmt.Errorf("%w, it became %s meanwhile", ErrOrderNotCancellable, order.Status)
+		}
+		if err := checkCancellable(tx, order); err != nil {
+			return err
+		}
+		var items []database.OrderItem
+		if err := tx.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+			return fmt.Errorf("failed to load order items")
+		}
+		for _, item := range items {
+			_, err := MoveStock(tx, StockChange{
+				ProductID: item.ProductId,
+				VariantID: item.VariantId,
+				Type:      database.StockMovementReturn,
+				Quantity:  item.Quantity,
+				Reason:    "order cancelled",
+				Reference: fmt.Sprintf("order:%d", order.ID),
+				ActorID:   actorID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := TransitionOrder(tx, &order, OrderStatusCancelled, actorID, "cancelled: "+reason); err != nil {
+			return err
+		}
+		return recordCancellation(tx, &order, reason)
+	})
+}
+
+// checkCancellable returns why the order cannot be cancelled: it is neither
+// awaiting payment nor paid, items of it have shipped, or a payment of it is
+// being processed.
+func checkCancellable(db *gorm.DB, order database.Order) error {
+	if order.Status != OrderStatusPending && order.Status != OrderStatusPaid {
+		return fmt.Errorf("%w, the order is %s", ErrOrderNotCancellable, order.Status)
+	}
+	var shipments int64
+	if err := db.Model(&database.Shipment{}).Where("order_id = ?", order.ID).Count(&shipments).Error; err != nil {
+		return fmt.Errorf("failed to load shipments")
+	}
+	if shipments > 0 {
+		return fmt.Errorf("%w, items of it have been shipped", ErrOrderNotCancellable)
+	}
+	inProgress, err := PaymentInProgress(db, order.ID)
+	if err != nil {
+		return err
+	}
+	if inProgress {
+		return ErrPaymentInProgress
+	}
+	return nil
+}
+
+// recordCancellation stores why and when an order was cancelled and gives
+// back its coupon uses.
+func recordCancellation(tx *gorm.DB, order *database.Order, reason string) error {
+	now := time.Now()
+	order.CancelReason = reason
+	order.CancelledAt = &now
+	if err := tx.Model(order).Select("cancel_reason", "cancelled_at").Updates(order).Error; err != nil {
+		return fmt.Errorf("failed to update order")
+	}
+	return releaseCouponRedemptions(tx, order.ID)
+}
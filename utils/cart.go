@@ -0,0 +1,303 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	defaultCartTokenTTL      = 30 * 24 * time.Hour
+	defaultCartSweepInterval = time.Hour
+	cartTokenAudience        = "cart"
+)
+
+var (
+	ErrCartNotFound     = errors.New("cart not found")
+	ErrInvalidCartToken = errors.New("invalid or expired cart token")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
+// CartClaims is the payload of a guest cart token issued by
+// GenerateCartToken. It names the cart and nothing else, so it cannot be
+// used as an access token.
+type CartClaims struct {
+	CartID uint `json:"cart"`
+	jwt.RegisteredClaims
+}
+
+// CartTokenTTL reads CART_TOKEN_TTL (e.g. "720h") and falls back to 30 days.
+func CartTokenTTL() time.Duration {
+	return durationFromEnv("CART_TOKEN_TTL", defaultCartTokenTTL)
+}
+
+// GenerateCartToken signs a token that lets whoever holds it use the guest
+// cart until CartTokenTTL from now.
+func GenerateCartToken(cartID uint) (string, error) {
+	jwtKey := os.Getenv("JWT_SECRET")
+	if jwtKey == "" {
+		return "", fmt.Errorf("JWT secret not configured")
+	}
+	now := time.Now()
+	claims := CartClaims{
+		CartID: cartID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{cartTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(CartTokenTTL())),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(jwtKey))
+}
+
+// ParseCartToken verifies a guest cart token and returns the cart it names.
+func ParseCartToken(tokenString string) (uint, error) {
+	jwtKey := os.Getenv("JWT_SECRET")
+	if jwtKey == "" {
+		return 0, fmt.Errorf("JWT secret not configured")
+	}
+	claims := &CartClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return []byte(jwtKey), nil
+	}, jwt.WithExpirationRequired(), jwt.WithAudience(cartTokenAudience))
+	if err != nil || !token.Valid || claims.CartID == 0 {
+		return 0, ErrInvalidCartToken
+	}
+	return claims.CartID, nil
+}
+
+// UserCart returns the user's cart. Without one it returns ErrCartNotFound,
+// or creates it when create is set.
+func UserCart(db *gorm.DB, userID uint, create bool) (database.Cart, error) {
+	var cart database.Cart
+	var user database.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return cart, ErrUserNotFound
+		}
+		return cart, fmt.Errorf("failed to load user")
+	}
+	if user.Cart != 0 {
+		err := db.Where("id = ? AND user_id = ?", user.Cart, user.ID).First(&cart).Error
+		if err == nil {
+			return cart, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return cart, fmt.Errorf("failed to load cart")
+		}
+	}
+	if !create {
+		return cart, ErrCartNotFound
+	}
+	cart = database.Cart{UserId: user.ID}
+	if err := db.Create(&cart).Error; err != nil {
+		return cart, fmt.Errorf("failed to create a cart")
+	}
+	if err := db.Model(&user).Update("cart", cart.ID).Error; err != nil {
+		return cart, fmt.Errorf("failed to update the user's cart")
+	}
+	return cart, nil
+}
+
+// GuestCart returns the guest cart a cart token names. Carts that have been
+// merged into a user's cart are gone, so their tokens find nothing.
+func GuestCart(db *gorm.DB, token string) (database.Cart, error) {
+	var cart database.Cart
+	cartID, err := ParseCartToken(token)
+	if err != nil {
+		return cart, err
+	}
+	if err := db.Where("id = ? AND user_id = ?", cartID, 0).First(&cart).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return cart, ErrCartNotFound
+		}
+		return cart, fmt.Errorf("failed to load cart")
+	}
+	return cart, nil
+}
+
+// CreateGuestCart creates a cart that belongs to no user and the token that
+// identifies it.
+func CreateGuestCart(db *gorm.DB) (database.Cart, string, error) {
+	cart := database.Cart{}
+	if err := db.Create(&cart).Error; err != nil {
+		return cart, "", fmt.Errorf("failed to create a cart")
+	}
+	token, err := GenerateCartToken(cart.ID)
+	if err != nil {
+		return cart, "", err
+	}
+	return cart, token, nil
+}
+
+// MergeGuestCart moves the items of the guest cart a token names into the
+// user's cart and deletes the guest cart. Items already in the user's cart
+// are added up, and each item is cut down to the stock other carts do not
+// hold; what could not be kept is described in the returned warnings. The
+// guest cart's coupon is kept when the user's cart has none. A token that
+// no longer names a guest cart leaves the user's cart untouched.
+func MergeGuestCart(userID uint, token string) ([]string, error) {
+	warnings := []string{}
+	guestID, err := ParseCartToken(token)
+	if err != nil {
+		return warnings, nil
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the user so concurrent logins merge into the same cart, and
+		// the guest cart so it is merged only once.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&database.User{}, userID).Error; err != nil {
+			return ErrUserNotFound
+		}
+		var guest database.Cart
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND user_id = ?", guestID, 0).First(&guest).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load cart")
+		}
+		var guestItems []database.CartItem
+		if err := tx.Where("cart_id = ?", guest.ID).Order("id").Find(&guestItems).Error; err != nil {
+			return fmt.Errorf("failed to load cart items")
+		}
+		cart, err := UserCart(tx, userID, true)
+		if err != nil {
+			return err
+		}
+		for _, guestItem := range guestItems {
+			warning, err := mergeCartItem(tx, cart, guestItem)
+			if err != nil {
+				return err
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+		if cart.CouponID == nil && guest.CouponID != nil {
+			if err := tx.Model(&cart).Update("coupon_id", *guest.CouponID).Error; err != nil {
+				return fmt.Errorf("failed to update cart")
+			}
+		}
+		if err := tx.Delete(&guest).Error; err != nil {
+			return fmt.Errorf("failed to delete the guest cart")
+		}
+		return nil
+	})
+	return warnings, err
+}
+
+// mergeCartItem moves a guest cart item into the user's cart, adding it to
+// the user's item for the same product and variant if there is one, and
+// holds stock for the result. The quantity added is cut down to the stock
+// that is available, and the returned warning says so.
+func mergeCartItem(tx *gorm.DB, cart database.Cart, guestItem database.CartItem) (string, error) {
+	stockItem, stockErr := ResolveStockItem(tx, guestItem.ProductId, guestItem.VariantId)
+	if stockErr != nil && !errors.Is(stockErr, ErrProductNotFound) && !errors.Is(stockErr, ErrVariantNotFound) && !errors.Is(stockErr, ErrVariantRequired) {
+		return "", stockErr
+	}
+	if err := ReleaseReservation(tx, guestItem.ID); err != nil {
+		return "", err
+	}
+	if err := tx.Delete(&guestItem).Error; err != nil {
+		return "", fmt.Errorf("failed to delete cart item")
+	}
+	if stockErr != nil {
+		return fmt.Sprintf("product %d is no longer available and was not added to your cart", guestItem.ProductId), nil
+	}
+	cartItem := database.CartItem{CartId: cart.ID, ProductId: guestItem.ProductId, VariantId: guestItem.VariantId}
+	err := tx.Where("cart_id = ? AND product_id = ? AND variant_id = ?", cart.ID, guestItem.ProductId, guestItem.VariantId).First(&cartItem).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("failed to load cart item")
+	}
+	onHand, err := lockStock(tx, guestItem.ProductId, guestItem.VariantId)
+	if err != nil {
+		return "", err
+	}
+	available, err := AvailableForCartItem(tx, cartItem, onHand)
+	if err != nil {
+		return "", err
+	}
+	added := min(guestItem.Quantity, max(available-cartItem.Quantity, 0))
+	warning := ""
+	if added < guestItem.Quantity {
+		warning = fmt.Sprintf("only %d of the %d %s in your guest cart could be added, the rest is out of stock", added, guestItem.Quantity, stockItem.Label())
+	}
+	if added == 0 {
+		return warning, nil
+	}
+	cartItem.Quantity += added
+	if err := tx.Save(&cartItem).Error; err != nil {
+		return "", fmt.Errorf("failed to save cart item")
+	}
+	return warning, ReserveCartItem(tx, cartItem)
+}
+
+// PurgeExpiredGuestCarts deletes the guest carts older than CartTokenTTL,
+// whose tokens have all expired, with their items, releasing the stock they
+// hold, and returns how many there were.
+func PurgeExpiredGuestCarts() (int64, error) {
+	var expired []database.Cart
+	if err := database.DB.Where("user_id = ? AND created_at < ?", 0, time.Now().Add(-CartTokenTTL())).Find(&expired).Error; err != nil {
+		return 0, fmt.Errorf("failed to load expired guest carts")
+	}
+	var purged int64
+	for _, cart := range expired {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND user_id = ?", cart.ID, 0).First(&cart).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load cart")
+			}
+			var items []database.CartItem
+			if err := tx.Where("cart_id = ?", cart.ID).Find(&items).Error; err != nil {
+				return fmt.Errorf("failed to load cart items")
+			}
+			for _, item := range items {
+				if err := ReleaseReservation(tx, item.ID); err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("cart_id = ?", cart.ID).Delete(&database.CartItem{}).Error; err != nil {
+				return fmt.Errorf("failed to delete cart items")
+			}
+			if err := tx.Delete(&cart).Error; err != nil {
+				return fmt.Errorf("failed to delete the guest cart")
+			}
+			purged++
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+// StartGuestCartSweeper deletes expired guest carts every
+// CART_SWEEP_INTERVAL (1 hour by default) for the life of the process.
+func StartGuestCartSweeper() {
+	interval := durationFromEnv("CART_SWEEP_INTERVAL", defaultCartSweepInterval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if purged, err := PurgeExpiredGuestCarts(); err != nil {
+				log.Println("failed to delete expired guest carts:", err)
+			} else if purged > 0 {
+				log.Printf("deleted %d expired guest carts", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"sort"
+)
+
+// CategoryNode is a category with its nested subcategories.
+type CategoryNode struct {
+	database.Category
+	Children []CategoryNode `json:"children"`
+}
+
+// BuildCategoryTree nests a flat list of categories under their parents.
+// Categories whose parent is missing are treated as roots.
+func BuildCategoryTree(categories []database.Category) []CategoryNode {
+	known := map[uint]bool{}
+	children := map[uint][]database.Category{}
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+	var roots []database.Category
+	for _, category := range categories {
+		if category.ParentID == nil || !known[*category.ParentID] {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+	var build func(level []database.Category) []CategoryNode
+	build = func(level []database.Category) []CategoryNode {
+		sort.Slice(level, func(i, j int) bool { return level[i].Name < level[j].Name })
+		nodes := make([]CategoryNode, 0, len(level))
+		for _, category := range level {
+			nodes = append(nodes, CategoryNode{Category: category, Children: build(children[category.ID])})
+		}
+		return nodes
+	}
+	return build(roots)
+}
+
+// CategoryDescendantIDs returns the id of the category and of every category
+// nested below it.
+func CategoryDescendantIDs(categoryID uint) ([]uint, error) {
+	var categories []database.Category
+	if err := database.DB.Select("id", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	children := map[uint][]uint{}
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+	ids := []uint{categoryID}
+	seen := map[uint]bool{categoryID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// Coupon types persisted on database.Coupon.
+const (
+	CouponTypePercentage   = "PERCENTAGE"
+	CouponTypeFixedAmount  = "FIXED_AMOUNT"
+	CouponTypeFreeShipping = "FREE_SHIPPING"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponInactive      = errors.New("coupon is not active")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrCouponMinSpend      = errors.New("cart total is below the coupon's minimum spend")
+	ErrCouponUsageLimit    = errors.New("coupon usage limit reached")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to any item in the cart")
+)
+
+// CouponLine is a cart or order line a coupon is evaluated against.
+type CouponLine struct {
+	ProductID uint
+	Quantity  int
+	UnitPrice database.Money
+}
+
+// CouponDiscount is the outcome of applying a coupon to a set of lines.
+// LineAmounts holds the share of Amount given to each line, in line order,
+// so partial refunds can give back what was actually paid for a line.
+type CouponDiscount struct {
+	Coupon       database.Coupon
+	Amount       database.Money
+	FreeShipping bool
+	LineAmounts  []database.Money
+}
+
+// IsCouponRejection reports whether err explains why a coupon cannot be used,
+// as opposed to a failure to check it.
+func IsCouponRejection(err error) bool {
+	for _, target := range []error{ErrCouponNotFound, ErrCouponInactive, ErrCouponExpired, ErrCouponMinSpend, ErrCouponUsageLimit, ErrCouponNotApplicable} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeCouponCode trims and upper-cases a code so lookups are case
+// insensitive.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// FindCoupon loads a coupon by code.
+func FindCoupon(db *gorm.DB, code string) (database.Coupon, error) {
+	var coupon database.Coupon
+	if err := db.Where("code = ?", NormalizeCouponCode(code)).First(&coupon).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return coupon, ErrCouponNotFound
+		}
+		return coupon, fmt.Errorf("failed to load coupon")
+	}
+	return coupon, nil
+}
+
+// EvaluateCoupon checks that the coupon can be used by the user on the lines
+// and computes the discount. Only lines of the coupon's products, or of
+// products in its categories and their subcategories, count towards the
+// minimum spend and are discounted; a coupon without either applies to every
+// line.
+func EvaluateCoupon(db *gorm.DB, coupon database.Coupon, userID uint, lines []CouponLine) (CouponDiscount, error) {
+	discount := CouponDiscount{Coupon: coupon, LineAmounts: make([]database.Money, len(lines))}
+	if !coupon.Active {
+		return discount, ErrCouponInactive
+	}
+	if coupon.ExpiresAt != nil && !time.Now().Before(*coupon.ExpiresAt) {
+		return discount, ErrCouponExpired
+	}
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return discount, ErrCouponUsageLimit
+	}
+	if coupon.PerUserLimit > 0 {
+		var used int64
+		if err := db.Model(&database.CouponRedemption{}).Where("coupon_id = ? AND user_id = ?", coupon.ID, userID).Count(&used).Error; err != nil {
+			return discount, fmt.Errorf("failed to load coupon usage")
+		}
+		if int(used) >= coupon.PerUserLimit {
+			return discount, fmt.Errorf("%w for this account", ErrCouponUsageLimit)
+		}
+	}
+	eligible, err := couponProducts(db, coupon)
+	if err != nil {
+		return discount, err
+	}
+	var subtotal database.Money
+	weights := make([]int64, len(lines))
+	for i, line := range lines {
+		if eligible == nil || eligible[line.ProductID] {
+			lineTotal := line.UnitPrice.Mul(line.Quantity)
+			subtotal = subtotal.Add(lineTotal)
+			weights[i] = lineTotal.Amount
+		}
+	}
+	if !subtotal.IsPositive() {
+		return discount, ErrCouponNotApplicable
+	}
+	// Amounts set in another currency are converted at today's rate.
+	minSpend, err := ConvertMoney(db, coupon.MinSpend, subtotal.Currency)
+	if err != nil {
+		return discount, couponConversionError(err)
+	}
+	amountOff, err := ConvertMoney(db, coupon.Amount, subtotal.Currency)
+	if err != nil {
+		return discount, couponConversionError(err)
+	}
+	if minSpend.IsPositive() && subtotal.LessThan(minSpend) {
+		return discount, fmt.Errorf("%w of %s", ErrCouponMinSpend, minSpend)
+	}
+	switch coupon.Type {
+	case CouponTypePercentage:
+		discount.Amount = subtotal.Percent(coupon.Value)
+	case CouponTypeFixedAmount:
+		discount.Amount = amountOff.Min(subtotal)
+	case CouponTypeFreeShipping:
+		discount.FreeShipping = true
+		return discount, nil
+	}
+
+	// Spread the discount over the eligible lines by value so the shares add
+	// up exactly.
+	discount.LineAmounts = discount.Amount.Allocate(weights)
+	return discount, nil
+}
+
+// RedeemCoupon locks the coupon, evaluates it against the order's lines and
+// records its use by the user. It must run inside the order's transaction so
+// usage limits hold under concurrent checkouts.
+func RedeemCoupon(tx *gorm.DB, couponID uint, userID uint, orderID uint, lines []CouponLine) (CouponDiscount, error) {
+	var coupon database.Coupon
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, couponID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return CouponDiscount{}, ErrCouponNotFound
+		}
+		return CouponDiscount{}, fmt.Errorf("failed to load coupon")
+	}
+	discount, err := EvaluateCoupon(tx, coupon, userID, lines)
+	if err != nil {
+		return discount, err
+	}
+	redemption := database.CouponRedemption{CouponID: coupon.ID, UserID: userID, OrderID: orderID}
+	if err := tx.Create(&redemption).Error; err != nil {
+		return discount, fmt.Errorf("failed to record coupon use")
+	}
+	if err := tx.Model(&coupon).Update("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+		return discount, fmt.Errorf("failed to record coupon use")
+	}
+	return discount, nil
+}
+
+func couponConversionError(err error) error {
+	if errors.Is(err, ErrUnsupportedCurrency) {
+		return fmt.Errorf("%w in this currency", ErrCouponNotApplicable)
+	}
+	return err
+}
+
+// couponProducts returns the ids of the products a coupon is scoped to, or
+// nil when it applies to every product.
+func couponProducts(db *gorm.DB, coupon database.Coupon) (map[uint]bool, error) {
+	if len(coupon.ProductIDs) == 0 && len(coupon.CategoryIDs) == 0 {
+		return nil, nil
+	}
+	products := map[uint]bool{}
+	for _, id := range coupon.ProductIDs {
+		products[id] = true
+	}
+	if len(coupon.CategoryIDs) > 0 {
+		var categoryIDs []uint
+		for _, id := range coupon.CategoryIDs {
+			descendants, err := CategoryDescendantIDs(id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load categories")
+			}
+			categoryIDs = append(categoryIDs, descendants...)
+		}
+		var productIDs []uint
+		if err := db.Table("product_categories").Where("category_id IN ?", categoryIDs).Pluck("product_id", &productIDs).Error; err != nil {
+			return nil, fmt.Errorf("failed to load category products")
+		}
+		for _, id := range productIDs {
+			products[id] = true
+		}
+	}
+	return products, nil
+}
+
+// releaseCouponRedemptions gives back the coupon uses of an order that will
+// not go ahead, so they no longer count against usage limits.
+func releaseCouponRedemptions(tx *gorm.DB, orderID uint) error {
+	var redemptions []database.CouponRedemption
+	if err := tx.Where("order_id = ?", orderID).Find(&redemptions).Error; err != nil {
+		return fmt.Errorf("failed to load coupon uses")
+	}
+	for _, redemption := range redemptions {
+		if err := tx.Delete(&redemption).Error; err != nil {
+			return fmt.Errorf("failed to release coupon use")
+		}
+		if err := tx.Model(&database.Coupon{}).Where("id = ? AND used_count > 0", redemption.CouponID).
+			Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+			return fmt.Errorf("failed to release coupon use")
+		}
+	}
+	return nil
+}
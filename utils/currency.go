@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sources recorded on database.ExchangeRate.
+const (
+	ExchangeRateSourceManual = "manual"
+	ExchangeRateSourceImport = "import"
+)
+
+var (
+	ErrUnsupportedCurrency   = errors.New("currency has no exchange rate")
+	ErrInvalidExchangeRate   = errors.New("exchange rate must be a positive number")
+	ErrExchangeRatesFile     = errors.New("cannot import exchange rates")
+	ErrBaseCurrencyRate      = errors.New("the base currency has no exchange rate")
+	ErrPriceListBaseCurrency = errors.New("list prices are for currencies other than the base currency")
+)
+
+// ExchangeRate returns how many units of currency one unit of the base
+// currency buys; the base currency itself is 1.
+func ExchangeRate(db *gorm.DB, currency string) (*big.Rat, error) {
+	currency = strings.ToUpper(currency)
+	if currency == database.DefaultCurrency() {
+		return big.NewRat(1, 1), nil
+	}
+	var rate database.ExchangeRate
+	if err := db.Where("currency = ?", currency).First(&rate).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+		}
+		return nil, fmt.Errorf("failed to load exchange rate")
+	}
+	return ratFromFloat(rate.Rate), nil
+}
+
+// IsSupportedCurrency reports whether prices can be shown and charged in
+// currency.
+func IsSupportedCurrency(db *gorm.DB, currency string) (bool, error) {
+	_, err := ExchangeRate(db, currency)
+	if errors.Is(err, ErrUnsupportedCurrency) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ConvertMoney converts an amount to currency through the base currency.
+func ConvertMoney(db *gorm.DB, amount database.Money, currency string) (database.Money, error) {
+	if !amount.IsSet() || amount.Currency == currency {
+		return amount, nil
+	}
+	from, err := ExchangeRate(db, amount.Currency)
+	if err != nil {
+		return amount, err
+	}
+	to, err := ExchangeRate(db, currency)
+	if err != nil {
+		return amount, err
+	}
+	return amount.Convert(currency, new(big.Rat).Quo(to, from)), nil
+}
+
+// SetExchangeRate creates or replaces the rate of a currency.
+func SetExchangeRate(db *gorm.DB, currency string, rate float64, source string, actorID uint) (database.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !database.ValidCurrency(currency) {
+		return database.ExchangeRate{}, fmt.Errorf("%w: %q is not an ISO 4217 code", ErrUnsupportedCurrency, currency)
+	}
+	if currency == database.DefaultCurrency() {
+		return database.ExchangeRate{}, ErrBaseCurrencyRate
+	}
+	if rate <= 0 {
+		return database.ExchangeRate{}, fmt.Errorf("%w: %s", ErrInvalidExchangeRate, currency)
+	}
+	exchangeRate := database.ExchangeRate{Currency: currency, Rate: rate, Source: source, UpdatedBy: actorID}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_by", "updated_at"}),
+	}).Create(&exchangeRate).Error
+	if err != nil {
+		return exchangeRate, fmt.Errorf("failed to save exchange rate")
+	}
+	var saved database.ExchangeRate
+	if err := db.Where("currency = ?", currency).First(&saved).Error; err != nil {
+		return exchangeRate, fmt.Errorf("failed to load exchange rate")
+	}
+	return saved, nil
+}
+
+// ImportExchangeRates loads the rates in EXCHANGE_RATES_FILE, either a CSV
+// file of currency,rate lines or a JSON object of rates by currency
+// (optionally under "rates" next to a "base" that must be the base
+// currency). Every rate is checked before any is saved.
+func ImportExchangeRates(actorID uint) ([]database.ExchangeRate, error) {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		return nil, fmt.Errorf("%w: EXCHANGE_RATES_FILE is not set", ErrExchangeRatesFile)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeRatesFile, err.Error())
+	}
+	defer file.Close()
+	var rates map[string]float64
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		rates, err = readRatesCSV(file)
+	} else {
+		rates, err = readRatesJSON(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeRatesFile, err.Error())
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: the file has no rates", ErrExchangeRatesFile)
+	}
+	base := database.DefaultCurrency()
+	currencies := make([]string, 0, len(rates))
+	for currency, rate := range rates {
+		if strings.ToUpper(currency) == base {
+			continue
+		}
+		if !database.ValidCurrency(strings.ToUpper(currency)) || rate <= 0 {
+			return nil, fmt.Errorf("%w: invalid rate for %q", ErrExchangeRatesFile, currency)
+		}
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	var imported []database.ExchangeRate
+	source := ExchangeRateSourceImport + ":" + filepath.Base(path)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, currency := range currencies {
+			exchangeRate, err := SetExchangeRate(tx, currency, rates[currency], source, actorID)
+			if err != nil {
+				return err
+			}
+			imported = append(imported, exchangeRate)
+		}
+		return nil
+	})
+	return imported, err
+}
+
+func readRatesCSV(r io.Reader) (map[string]float64, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rates := map[string]float64{}
+	for i, record := range records {
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			if i == 0 {
+				// A header line.
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %q is not a rate", i+1, record[1])
+		}
+		rates[strings.TrimSpace(record[0])] = rate
+	}
+	return rates, nil
+}
+
+func readRatesJSON(r io.Reader) (map[string]float64, error) {
+	var document map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return nil, err
+	}
+	rates := map[string]float64{}
+	if nested, ok := document["rates"]; ok {
+		var base string
+		if raw, ok := document["base"]; ok {
+			if err := json.Unmarshal(raw, &base); err != nil {
+				return nil, err
+			}
+		}
+		if base != "" && strings.ToUpper(base) != database.DefaultCurrency() {
+			return nil, fmt.Errorf("rates are relative to %s, not the base currency %s", base, database.DefaultCurrency())
+		}
+		return rates, json.Unmarshal(nested, &rates)
+	}
+	for currency, raw := range document {
+		var rate float64
+		if err := json.Unmarshal(raw, &rate); err != nil {
+			return nil, fmt.Errorf("%q is not a rate", currency)
+		}
+		rates[currency] = rate
+	}
+	return rates, nil
+}
+
+// PriceList resolves prices in one currency: a list price when the product
+// or variant has one, otherwise its base price converted at the exchange rate.
+type PriceList struct {
+	Currency string
+	rate     *big.Rat
+	prices   map[[2]uint]database.Money
+}
+
+// LoadPriceList loads the list prices in currency of the given products.
+func LoadPriceList(db *gorm.DB, currency string, productIDs []uint) (PriceList, error) {
+	rate, err := ExchangeRate(db, currency)
+	if err != nil {
+		return PriceList{}, err
+	}
+	list := PriceList{Currency: strings.ToUpper(currency), rate: rate, prices: map[[2]uint]database.Money{}}
+	if list.Currency == database.DefaultCurrency() || len(productIDs) == 0 {
+		return list, nil
+	}
+	var prices []database.ProductPrice
+	if err := db.Where("product_id IN ? AND price_currency = ?", productIDs, list.Currency).Find(&prices).Error; err != nil {
+		return list, fmt.Errorf("failed to load price lists")
+	}
+	for _, price := range prices {
+		list.prices[[2]uint{price.ProductID, price.VariantID}] = price.Price
+	}
+	return list, nil
+}
+
+// ProductPrice is the product's price in the list's currency.
+func (l PriceList) ProductPrice(product database.Product) database.Money {
+	if price, ok := l.prices[[2]uint{product.ID, 0}]; ok {
+		return price
+	}
+	return l.convert(product.Price)
+}
+
+// VariantPrice is the variant's price override in the list's currency, unset
+// when the product's price applies.
+func (l PriceList) VariantPrice(variant database.ProductVariant) database.Money {
+	if price, ok := l.prices[[2]uint{variant.ProductID, variant.ID}]; ok {
+		return price
+	}
+	return l.convert(variant.Price)
+}
+
+// ProductPriceExpr is an SQL expression for a product's price in the list's
+// currency, in its minor units: its list price when it has one, otherwise its
+// base price converted at the list's rate and rounded. Products are filtered
+// and sorted on it.
+func (l PriceList) ProductPriceExpr() clause.Expr {
+	if l.Currency == database.DefaultCurrency() {
+		return clause.Expr{SQL: "products.price_amount"}
+	}
+	shift := database.CurrencyExponent(l.Currency) - database.CurrencyExponent(database.DefaultCurrency())
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(shift, -shift))), nil))
+	if shift < 0 {
+		scale.Inv(scale)
+	}
+	return gorm.Expr(`COALESCE((SELECT product_prices.price_amount FROM product_prices
+		WHERE product_prices.product_id = products.id AND product_prices.variant_id = 0 AND product_prices.price_currency = ?),
+		CAST(ROUND(products.price_amount * CAST(? AS NUMERIC)) AS BIGINT))`, l.Currency, new(big.Rat).Mul(l.rate, scale).FloatString(12))
+}
+
+// Rate is the exchange rate of the list's currency.
+func (l PriceList) Rate() float64 {
+	rate, _ := l.rate.Float64()
+	return rate
+}
+
+// ToBase converts an amount in the list's currency to the base currency at
+// the list's rate.
+func (l PriceList) ToBase(amount database.Money) database.Money {
+	if amount.Currency != l.Currency || l.Currency == database.DefaultCurrency() {
+		return amount
+	}
+	return amount.Convert(database.DefaultCurrency(), new(big.Rat).Inv(l.rate))
+}
+
+// FromBase converts an amount in the base currency to the list's currency at
+// the list's rate.
+func (l PriceList) FromBase(amount database.Money) database.Money {
+	return l.convert(amount)
+}
+
+// UnitPrice is the price a stock item sells for in the list's currency.
+func (l PriceList) UnitPrice(item StockItem) database.Money {
+	if item.Variant != nil {
+		if price := l.VariantPrice(*item.Variant); price.IsSet() {
+			return price
+		}
+	}
+	return l.ProductPrice(item.Product)
+}
+
+// Localize replaces the prices of the products and their loaded variants
+// with their prices in the list's currency.
+func (l PriceList) Localize(products []database.Product) {
+	for i := range products {
+		products[i].Price = l.ProductPrice(products[i])
+		l.LocalizeVariants(products[i].Variants)
+	}
+}
+
+// LocalizeVariants replaces the variants' price overrides with their prices
+// in the list's currency.
+func (l PriceList) LocalizeVariants(variants []database.ProductVariant) {
+	for i := range variants {
+		variants[i].Price = l.VariantPrice(variants[i])
+	}
+}
+
+func (l PriceList) convert(price database.Money) database.Money {
+	if !price.IsSet() || price.Currency == l.Currency {
+		return price
+	}
+	return price.Convert(l.Currency, l.rate)
+}
+
+func ratFromFloat(value float64) *big.Rat {
+	rate, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return rate
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+// Statuses of a database.IdempotencyKey.
+const (
+	IdempotencyStatusInFlight  = "IN_FLIGHT"
+	IdempotencyStatusCompleted = "COMPLETED"
+)
+
+const (
+	defaultIdempotencyKeyTTL      = 24 * time.Hour
+	defaultIdempotencySweepPeriod = time.Hour
+	defaultIdempotencyLockTimeout = 5 * time.Minute
+)
+
+var (
+	ErrIdempotencyKeyInFlight = errors.New("a request with this Idempotency-Key is still being processed, retry later")
+	ErrIdempotencyKeyReused   = errors.New("this Idempotency-Key was already used for a different request")
+)
+
+// IdempotencyKeyTTL reads IDEMPOTENCY_KEY_TTL, how long responses are kept
+// for retries, and falls back to 24 hours.
+func IdempotencyKeyTTL() time.Duration {
+	return durationFromEnv("IDEMPOTENCY_KEY_TTL", defaultIdempotencyKeyTTL)
+}
+
+// IdempotencyLockTimeout reads IDEMPOTENCY_LOCK_TIMEOUT and falls back to 5
+// minutes. A request still running keeps its key fresh, so a key in flight
+// for longer than this is taken to have died with its server, and a retry
+// may run the request again. It must be well above the payment gateway's
+// timeout.
+func IdempotencyLockTimeout() time.Duration {
+	return durationFromEnv("IDEMPOTENCY_LOCK_TIMEOUT", defaultIdempotencyLockTimeout)
+}
+
+// BeginIdempotentRequest claims a user's idempotency key for a request with
+// the given fingerprint. It returns the claimed key and false when the
+// request should run, or the completed key and true when its stored
+// response should be replayed.
+func BeginIdempotentRequest(userID uint, key string, fingerprint string) (database.IdempotencyKey, bool, error) {
+	record := database.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      IdempotencyStatusInFlight,
+		ExpiresAt:   time.Now().Add(IdempotencyKeyTTL()),
+	}
+	res := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if res.Error != nil {
+		return record, false, fmt.Errorf("failed to save idempotency key")
+	}
+	if res.RowsAffected == 1 {
+		return record, false, nil
+	}
+	var existing database.IdempotencyKey
+	if err := database.DB.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error; err != nil {
+		return record, false, fmt.Errorf("failed to load idempotency key")
+	}
+	now := time.Now()
+	expired := existing.ExpiresAt.Before(now)
+	abandoned := existing.Status == IdempotencyStatusInFlight && existing.UpdatedAt.Before(now.Add(-IdempotencyLockTimeout()))
+	if !expired && existing.Fingerprint != fingerprint {
+		return existing, false, ErrIdempotencyKeyReused
+	}
+	if !expired && !abandoned {
+		if existing.Status == IdempotencyStatusInFlight {
+			return existing, false, ErrIdempotencyKeyInFlight
+		}
+		return existing, true, nil
+	}
+	// Take the stale key over, unless another retry got to it first.
+	res = database.DB.Model(&database.IdempotencyKey{}).
+		Where("id = ? AND status = ? AND updated_at = ?", existing.ID, existing.Status, existing.UpdatedAt).
+		Updates(map[string]interface{}{
+			"fingerprint":   fingerprint,
+			"status":        IdempotencyStatusInFlight,
+			"response_code": 0,
+			"content_type":  "",
+			"response_body": "",
+			"expires_at":    record.ExpiresAt,
+			"updated_at":    now,
+		})
+	if res.Error != nil {
+		return existing, false, fmt.Errorf("failed to save idempotency key")
+	}
+	if res.RowsAffected == 0 {
+		return existing, false, ErrIdempotencyKeyInFlight
+	}
+	record.ID = existing.ID
+	return record, false, nil
+}
+
+// HoldIdempotentRequest keeps a claimed key fresh while its request runs,
+// so a slow request is not taken to be abandoned. The returned function
+// stops it.
+func HoldIdempotentRequest(record database.IdempotencyKey) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(IdempotencyLockTimeout() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := database.DB.Model(&database.IdempotencyKey{}).
+					Where("id = ? AND status = ?", record.ID, IdempotencyStatusInFlight).
+					Update("updated_at", time.Now()).Error
+				if err != nil {
+					log.Printf("failed to refresh idempotency key %d: %v", record.ID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// CompleteIdempotentRequest stores the response of a claimed key for retries
+// to replay.
+func CompleteIdempotentRequest(record database.IdempotencyKey, code int, contentType string, body []byte) error {
+	err := database.DB.Model(&database.IdempotencyKey{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+		"status":        IdempotencyStatusCompleted,
+		"response_code": code,
+		"content_type":  contentType,
+		"response_body": string(body),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response")
+	}
+	return nil
+}
+
+// ReleaseIdempotentRequest gives up a claimed key whose request failed
+// without a response worth replaying, so a retry runs it again.
+func ReleaseIdempotentRequest(record database.IdempotencyKey) error {
+	if err := database.DB.Where("id = ? AND status = ?", record.ID, IdempotencyStatusInFlight).Delete(&database.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("failed to release idempotency key")
+	}
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys deletes the keys whose responses are no longer
+// kept and returns how many there were.
+func PurgeExpiredIdempotencyKeys() (int64, error) {
+	res := database.DB.Where("expires_at < ?", time.Now()).Delete(&database.IdempotencyKey{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys")
+	}
+	return res.RowsAffected, nil
+}
+
+// StartIdempotencyKeySweeper deletes expired idempotency keys every
+// IDEMPOTENCY_SWEEP_INTERVAL (1 hour by default) for the life of the process.
+func StartIdempotencyKeySweeper() {
+	interval := durationFromEnv("IDEMPOTENCY_SWEEP_INTERVAL", defaultIdempotencySweepPeriod)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if purged, err := PurgeExpiredIdempotencyKeys(); err != nil {
+				log.Println("failed to delete expired idempotency keys:", err)
+			} else if purged > 0 {
+				log.Printf("deleted %d expired idempotency keys", purged)
+			}
+		}
+	}()
+}
@@ -10,70 +10,82 @@ import (
 
 // jwtKey will be loaded from environment in each function
 
-func GenerateToken(UserId uint) (string, error) {
-	jwtKey := os.Getenv("JWT_SECRET")
-	if jwtKey == "" {
-		return "", fmt.Errorf("JWT secret not configured")
-	}
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["id"] = UserId
-	claims["exp"] = time.Now().Add(time.Hour * 24)
-	return token.SignedString([]byte(jwtKey))
+const defaultAccessTokenTTL = 15 * time.Minute
+
+// Claims is the payload carried by every access token issued by GenerateToken.
+type Claims struct {
+	UserID    uint     `json:"id"`
+	SessionID string   `json:"sid"`
+	Roles     []string `json:"roles"`
+	jwt.RegisteredClaims
 }
-func ValidateToken(tokenStr string) (uint, error) {
+
+// AccessTokenTTL reads ACCESS_TOKEN_TTL (e.g. "15m") and falls back to 15 minutes.
+func AccessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+// GenerateToken issues a short-lived access token bound to the refresh token
+// family (sessionID) it was minted with, so that revoking the family also
+// invalidates the access token. The user's roles are embedded for RBAC checks.
+func GenerateToken(userID uint, sessionID string, roles []string) (string, error) {
 	jwtKey := os.Getenv("JWT_SECRET")
 	if jwtKey == "" {
-		return 0, fmt.Errorf("JWT secret not configured")
-	}
-	parsedToken, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		return []byte(jwtKey), nil
-	})
-	if err != nil {
-		return 0, err
+		return "", fmt.Errorf("JWT secret not configured")
 	}
-	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
-		userIDFloat, ok := claims["id"].(float64)
-		if !ok {
-			return 0, fmt.Errorf("error parsing user id from claims")
-		}
-		return uint(userIDFloat), nil
+	now := time.Now()
+	claims := Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Roles:     roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+		},
 	}
-	return 0, fmt.Errorf("invalid token")
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(jwtKey))
 }
-func ParseToken(tokenString string) (uint, error) {
+
+// ParseToken verifies the signature and expiry of an access token and returns its claims.
+func ParseToken(tokenString string) (*Claims, error) {
 	if tokenString == "" {
-		return 0, errors.New("empty token string")
+		return nil, errors.New("empty token string")
 	}
 	jwtKey := os.Getenv("JWT_SECRET")
 	if jwtKey == "" {
-		return 0, fmt.Errorf("JWT secret not configured")
+		return nil, fmt.Errorf("JWT secret not configured")
 	}
-	fmt.Println("JWT_SECRET length:", len(jwtKey))
-	fmt.Println("tokenString", tokenString)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method")
 		}
 		return []byte(jwtKey), nil
-	})
-	fmt.Println("token", token)
+	}, jwt.WithExpirationRequired())
 	if err != nil {
-		fmt.Println("JWT parse error:", err)
-		return 0, err
+		return nil, err
 	}
-	
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		fmt.Println("Claims:", claims)
-		if idClaim, ok := claims["id"].(float64); ok {
-			fmt.Println("Found id claim:", idClaim)
-			return uint(idClaim), nil
-		}
-		fmt.Println("id claim not found or not float64")
-		return 0, errors.New("token does not contain valid id")
+	if !token.Valid {
+		return nil, errors.New("invalid token")
 	}
-	
-	fmt.Println("Token not valid or claims not ok")
-	return 0, errors.New("invalid token")
+	if claims.UserID == 0 {
+		return nil, errors.New("token does not contain valid id")
+	}
+	if claims.SessionID == "" {
+		return nil, errors.New("token does not contain a session")
+	}
+	return claims, nil
 }
 
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+)
+
+// Order lifecycle statuses.
+const (
+	OrderStatusPending   = "PENDING"
+	OrderStatusPaid      = "PAID"
+	OrderStatusShipped   = "SHIPPED"
+	OrderStatusDelivered = "DELIVERED"
+	OrderStatusCancelled = "CANCELLED"
+	OrderStatusRefunded  = "REFUNDED"
+)
+
+// Refund states of database.Order, kept apart from its status so a partial
+// refund does not hide how far the order has been fulfilled.
+const (
+	OrderRefundPartial = "PARTIALLY_REFUNDED"
+	OrderRefundFull    = "REFUNDED"
+)
+
+// orderTransitions lists, for every status, the statuses an order may move to.
+var orderTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {OrderStatusRefunded},
+	OrderStatusRefunded:  {},
+}
+
+var ErrOrderStatusChanged = errors.New("order status was changed by another request, retry")
+
+// InvalidTransitionError is returned when an order cannot move between two statuses.
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("order cannot move from %s to %s", e.From, e.To)
+}
+
+// CanTransitionOrder reports whether an order in status from may move to status to.
+func CanTransitionOrder(from, to string) bool {
+	for _, allowed := range orderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionOrder moves an order to a new status and records the change in
+// its history. The update only applies if the order still has the status it
+// was loaded with, so concurrent transitions cannot overwrite each other.
+func TransitionOrder(db *gorm.DB, order *database.Order, to string, actorID uint, note string) error {
+	from := order.Status
+	if !CanTransitionOrder(from, to) {
+		return &InvalidTransitionError{From: from, To: to}
+	}
+	res := db.Model(&database.Order{}).Where("id = ? AND status = ?", order.ID, from).Update("status", to)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update order status")
+	}
+	if res.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	order.Status = to
+	return RecordOrderStatus(db, order.ID, from, to, actorID, note)
+}
+
+// RecordOrderStatus appends an entry to the order's status history.
+func RecordOrderStatus(db *gorm.DB, orderID uint, from, to string, actorID uint, note string) error {
+	entry := database.OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  actorID,
+		Note:       note,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record order status history")
+	}
+	return nil
+}
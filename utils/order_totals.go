@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderTotalMismatch    = errors.New("order total does not match its items")
+	ErrPaymentAmountMismatch = errors.New("payment amount does not match the order total")
+)
+
+// CalculateOrderTotals sets the subtotal, discount total, tax total and
+// grand total of an order from the prices its items were bought at, their
+// tax and the order's discounts. The shipping total is taken as it is. Tax
+// is only added to the grand total when prices did not include it.
+func CalculateOrderTotals(order *database.Order, items []database.OrderItem, discounts []database.OrderDiscount) {
+	currency := database.DefaultCurrency()
+	if len(items) > 0 {
+		currency = items[0].Price.Currency
+	}
+	subtotal, discountTotal, taxTotal := database.NewMoney(0, currency), database.NewMoney(0, currency), database.NewMoney(0, currency)
+	for _, item := range items {
+		subtotal = subtotal.Add(item.Price.Mul(item.Quantity))
+		taxTotal = taxTotal.Add(item.Tax)
+	}
+	for _, discount := range discounts {
+		discountTotal = discountTotal.Add(discount.Amount)
+	}
+	order.Subtotal = subtotal
+	order.DiscountTotal = discountTotal
+	order.TaxTotal = taxTotal
+	order.ShippingTotal = order.ShippingTotal.Add(database.NewMoney(0, currency))
+	order.GrandTotal = subtotal.Sub(discountTotal).Add(order.ShippingTotal)
+	if !order.TaxInclusive {
+		order.GrandTotal = order.GrandTotal.Add(taxTotal)
+	}
+	if order.GrandTotal.IsNegative() {
+		order.GrandTotal = database.NewMoney(0, currency)
+	}
+}
+
+// VerifyOrderTotals recomputes the totals of a placed order from its stored
+// items and discounts and fails when they no longer match what was stored.
+func VerifyOrderTotals(db *gorm.DB, order database.Order) error {
+	var items []database.OrderItem
+	if err := db.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load order items")
+	}
+	var discounts []database.OrderDiscount
+	if err := db.Where("order_id = ?", order.ID).Find(&discounts).Error; err != nil {
+		return fmt.Errorf("failed to load order discounts")
+	}
+	expected := order
+	CalculateOrderTotals(&expected, items, discounts)
+	if expected.GrandTotal != order.GrandTotal {
+		return fmt.Errorf("%w: stored %s, items add up to %s", ErrOrderTotalMismatch, order.GrandTotal, expected.GrandTotal)
+	}
+	return nil
+}
+
+// checkCapturedAmount fails a capture for another amount than was charged
+// and gives the captured money back.
+func checkCapturedAmount(gateway PaymentGateway, resp GatewayResponse, gwErr error, amount database.Money) error {
+	if gwErr != nil || resp.Status != GatewayStatusCaptured || resp.Amount == amount {
+		return gwErr
+	}
+	gateway.Refund(resp.TransactionID, resp.Amount)
+	return fmt.Errorf("%w: gateway captured %s instead of %s", ErrPaymentAmountMismatch, resp.Amount, amount)
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageParams are the paging and sorting options of a list request. Either
+// Page (offset pagination) or Cursor (keyset pagination) is used; a cursor
+// takes precedence when both are given.
+type PageParams struct {
+	Page     int
+	PageSize int
+	Cursor   string
+	Sort     string
+	Desc     bool
+	column   string
+}
+
+// PageMeta is returned next to the items of every paginated list.
+type PageMeta struct {
+	Total      int64  `json:"total" example:"42"`
+	Page       int    `json:"page,omitempty" example:"1"`
+	PageSize   int    `json:"page_size" example:"20"`
+	Sort       string `json:"sort" example:"created_at"`
+	Order      string `json:"order" example:"desc"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJ2IjoiMjAyNS0wMS0wMVQwMDowMDowMFoiLCJpZCI6MjB9"`
+}
+
+type cursorKey struct {
+	Value interface{} `json:"v"`
+	ID    uint        `json:"id"`
+}
+
+// ParsePageParams reads page, page_size, cursor, sort and order from the query
+// string. sortColumns maps the sort names clients may use to database columns;
+// qualify them ("products.price") when the list query joins other tables.
+func ParsePageParams(c *gin.Context, sortColumns map[string]string, defaultSort string, defaultDesc bool) (PageParams, error) {
+	params := PageParams{Page: 1, PageSize: DefaultPageSize, Sort: defaultSort, Desc: defaultDesc}
+	var err error
+	if value := c.Query("page"); value != "" {
+		if params.Page, err = strconv.Atoi(value); err != nil || params.Page < 1 {
+			return params, errors.New("page must be a positive number")
+		}
+	}
+	if value := c.Query("page_size"); value != "" {
+		if params.PageSize, err = strconv.Atoi(value); err != nil || params.PageSize < 1 || params.PageSize > MaxPageSize {
+			return params, fmt.Errorf("page_size must be between 1 and %d", MaxPageSize)
+		}
+	}
+	if value := c.Query("sort"); value != "" {
+		params.Sort = strings.ToLower(value)
+	}
+	column, ok := sortColumns[params.Sort]
+	if !ok {
+		allowed := make([]string, 0, len(sortColumns))
+		for name := range sortColumns {
+			allowed = append(allowed, name)
+		}
+		sort.Strings(allowed)
+		return params, fmt.Errorf("sort must be one of %s", strings.Join(allowed, ", "))
+	}
+	params.column = column
+	switch strings.ToLower(c.Query("order")) {
+	case "":
+	case "asc":
+		params.Desc = false
+	case "desc":
+		params.Desc = true
+	default:
+		return params, errors.New("order must be asc or desc")
+	}
+	params.Cursor = c.Query("cursor")
+	return params, nil
+}
+
+// Paginate runs a filtered query one page at a time, ordered by the sort
+// column with the primary key as tie breaker. keyOf returns the sort value
+// and id of a row and is used to build the next cursor.
+func Paginate[T any](query *gorm.DB, params PageParams, keyOf func(T) (interface{}, uint)) ([]T, PageMeta, error) {
+	idColumn := "id"
+	if table, _, found := strings.Cut(params.column, "."); found {
+		idColumn = table + ".id"
+	}
+	meta := PageMeta{PageSize: params.PageSize, Sort: params.Sort, Order: "asc"}
+	if params.Desc {
+		meta.Order = "desc"
+	}
+	if err := query.Session(&gorm.Session{}).Count(&meta.Total).Error; err != nil {
+		return nil, meta, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if params.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	pageQuery := query.Session(&gorm.Session{}).
+		Order(fmt.Sprintf("%s %s, %s %s", params.column, direction, idColumn, direction)).
+		Limit(params.PageSize + 1)
+	if params.Cursor != "" {
+		key, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, meta, err
+		}
+		pageQuery = pageQuery.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND %s %s ?)", params.column, comparison, params.column, idColumn, comparison),
+			key.Value, key.Value, key.ID,
+		)
+	} else {
+		meta.Page = params.Page
+		pageQuery = pageQuery.Offset((params.Page - 1) * params.PageSize)
+	}
+
+	items := []T{}
+	if err := pageQuery.Find(&items).Error; err != nil {
+		return nil, meta, err
+	}
+	if len(items) > params.PageSize {
+		items = items[:params.PageSize]
+		meta.HasMore = true
+		value, id := keyOf(items[len(items)-1])
+		meta.NextCursor = encodeCursor(cursorKey{Value: value, ID: id})
+	}
+	return items, meta, nil
+}
+
+func encodeCursor(key cursorKey) string {
+	raw, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(cursor string) (cursorKey, error) {
+	var key cursorKey
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return key, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &key); err != nil || key.Value == nil {
+		return key, ErrInvalidCursor
+	}
+	return key, nil
+}
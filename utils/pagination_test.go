@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		key  cursorKey
+		want interface{}
+	}{
+		{cursorKey{Value: "2025-01-01T00:00:00Z", ID: 20}, "2025-01-01T00:00:00Z"},
+		{cursorKey{Value: "iPhone 15", ID: 3}, "iPhone 15"},
+		// JSON numbers decode as float64.
+		{cursorKey{Value: int64(99999), ID: 7}, float64(99999)},
+		{cursorKey{Value: 0, ID: 1}, float64(0)},
+	}
+	for _, tt := range tests {
+		cursor := encodeCursor(tt.key)
+		got, err := decodeCursor(cursor)
+		if err != nil {
+			t.Errorf("decodeCursor(%q) error = %v", cursor, err)
+			continue
+		}
+		if got.Value != tt.want || got.ID != tt.key.ID {
+			t.Errorf("decodeCursor(encodeCursor(%v)) = %v, want {%v %d}", tt.key, got, tt.want, tt.key.ID)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []string{
+		"",
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte(`{"v":"a","id":1}`)),
+		encode(`not json`),
+		encode(`{"id":1}`),
+		encode(`{"v":null,"id":1}`),
+		encode(`{"v":"a","id":-1}`),
+	}
+	for _, cursor := range tests {
+		if _, err := decodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
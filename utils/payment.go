@@ -1,40 +1,226 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
 )
 
-func ProcessPayment(orderID uint, paymentMethod string) (database.Payment, error) {
-	var order database.Order
-	if err := database.DB.First(&order, orderID).Error; err != nil {
-		return database.Payment{}, fmt.Errorf("order not found")
-	}
-	amount := 0.0
-	var cart database.Cart
-	if err := database.DB.First(&cart, order.Cart).Error; err == nil {
-		var cartItems []database.CartItem
-		database.DB.Where("cart_id = ?", cart.ID).Find(&cartItems)
-		for _, item := range cartItems {
-			var product database.Product
-			if err := database.DB.First(&product, item.ProductId).Error; err == nil {
-				amount += float64(item.Quantity) * product.Price
+// Payment statuses persisted on database.Payment. A payment is PROCESSING
+// while the gateway is being called for it.
+const (
+	PaymentStatusPaid       = "PAID"
+	PaymentStatusPending    = "PENDING"
+	PaymentStatusProcessing = "PROCESSING"
+	PaymentStatusDeclined   = "DECLINED"
+	PaymentStatusFailed     = "FAILED"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderNotPayable   = errors.New("order is not awaiting payment")
+	ErrPaymentNotFound   = errors.New("payment not found")
+	ErrPaymentDeclined   = errors.New("payment was declined")
+	ErrPaymentNotPending = errors.New("payment is not awaiting confirmation")
+	ErrPaymentInProgress = errors.New("a payment for this order is already being processed or awaiting confirmation")
+)
+
+// ProcessPayment charges the grand total stored on an order when it was
+// placed through the active PaymentGateway. Every attempt, successful or not,
+// is recorded as a database.Payment. The payment is saved PROCESSING under a
+// lock on the order before the gateway is called, so concurrent attempts
+// cannot charge the order twice. A payment left PENDING must later be
+// settled with ConfirmPayment.
+func ProcessPayment(orderID uint, paymentMethod string, actorID uint) (database.Payment, error) {
+	gateway, err := ActivePaymentGateway()
+	if err != nil {
+		return database.Payment{}, err
+	}
+	var payment database.Payment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var order database.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
 			}
+			return fmt.Errorf("failed to load order")
+		}
+		if !CanTransitionOrder(order.Status, OrderStatusPaid) {
+			return ErrOrderNotPayable
+		}
+		inProgress, err := PaymentInProgress(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if inProgress {
+			return ErrPaymentInProgress
+		}
+		if err := VerifyOrderTotals(tx, order); err != nil {
+			return err
+		}
+		payment = database.Payment{
+			OrderID:        order.ID,
+			Amount:         order.GrandTotal,
+			ExchangeRate:   order.ExchangeRate,
+			BaseAmount:     order.BaseGrandTotal,
+			RefundedAmount: database.NewMoney(0, order.GrandTotal.Currency),
+			PaymentMethod:  paymentMethod,
+			Gateway:        gateway.Name(),
+			Status:         PaymentStatusProcessing,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to record payment")
+		}
+		return nil
+	})
+	if err != nil {
+		return database.Payment{}, err
+	}
+
+	amount := payment.Amount
+	resp, gwErr := gateway.Authorize(ChargeRequest{OrderID: payment.OrderID, Amount: amount, PaymentMethod: paymentMethod})
+	if gwErr == nil && resp.Status == GatewayStatusAuthorized {
+		authorization := resp
+		resp, gwErr = gateway.Capture(authorization.TransactionID, amount)
+		if gwErr != nil {
+			if _, err := gateway.Void(authorization.TransactionID); err != nil {
+				log.Printf("failed to void authorization %s of payment %d after its capture failed: %v", authorization.TransactionID, payment.ID, err)
+			}
+		}
+	}
+	gwErr = checkCapturedAmount(gateway, resp, gwErr, amount)
+	applyGatewayResponse(&payment, resp, gwErr)
+	return settlePayment(gateway, payment, gwErr, actorID)
+}
+
+// ConfirmPayment asks the gateway for the outcome of a PENDING payment and
+// updates the payment and its order accordingly. The payment is claimed
+// PROCESSING first so concurrent confirmations cannot capture it twice.
+func ConfirmPayment(paymentID uint, actorID uint) (database.Payment, error) {
+	var payment database.Payment
+	if err := database.DB.First(&payment, paymentID).Error; err != nil {
+		return database.Payment{}, ErrPaymentNotFound
+	}
+	if payment.Status != PaymentStatusPending {
+		return payment, ErrPaymentNotPending
+	}
+	gateway, err := ActivePaymentGateway()
+	if err != nil {
+		return payment, err
+	}
+	res := database.DB.Model(&database.Payment{}).Where("id = ? AND status = ?", payment.ID, PaymentStatusPending).Update("status", PaymentStatusProcessing)
+	if res.Error != nil {
+		return payment, fmt.Errorf("failed to update payment")
+	}
+	if res.RowsAffected == 0 {
+		return payment, ErrPaymentNotPending
+	}
+	resp, gwErr := gateway.FetchStatus(payment.TransactionID)
+	if errors.Is(gwErr, ErrGatewayTimeout) {
+		// Nothing is known yet; leave the payment pending so it can be retried.
+		if err := database.DB.Model(&payment).Update("status", PaymentStatusPending).Error; err != nil {
+			log.Printf("failed to put payment %d back to pending: %v", payment.ID, err)
 		}
+		return payment, gwErr
+	}
+	if gwErr == nil && resp.Status == GatewayStatusAuthorized {
+		resp, gwErr = gateway.Capture(payment.TransactionID, payment.Amount)
 	}
-	payment := database.Payment{
-		OrderID:       order.ID,
-		Amount:        amount,
-		Status:        "PAID",
-		PaymentMethod: paymentMethod,
-		TransactionID: fmt.Sprintf("TXN-%d", order.ID),
+	gwErr = checkCapturedAmount(gateway, resp, gwErr, payment.Amount)
+	applyGatewayResponse(&payment, resp, gwErr)
+	return settlePayment(gateway, payment, gwErr, actorID)
+}
+
+// PaymentInProgress reports whether the order has a payment the gateway is
+// processing or that awaits confirmation.
+func PaymentInProgress(db *gorm.DB, orderID uint) (bool, error) {
+	var count int64
+	err := db.Model(&database.Payment{}).
+		Where("order_id = ? AND status IN ?", orderID, []string{PaymentStatusProcessing, PaymentStatusPending}).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to load payments")
+	}
+	return count > 0, nil
+}
+
+func applyGatewayResponse(payment *database.Payment, resp GatewayResponse, gwErr error) {
+	if resp.TransactionID != "" {
+		payment.TransactionID = resp.TransactionID
 	}
-	if err := database.DB.Create(&payment).Error; err != nil {
-		return database.Payment{}, fmt.Errorf("failed to record payment")
+	payment.GatewayCode = resp.Code
+	payment.GatewayMessage = resp.Message
+	payment.GatewayResponse = resp.Raw
+	if gwErr != nil {
+		payment.Status = PaymentStatusFailed
+		payment.GatewayMessage = gwErr.Error()
+		return
 	}
-	order.Status = "PAID"
-	if err := database.DB.Save(&order).Error; err != nil {
-		return payment, fmt.Errorf("failed to update order status")
+	switch resp.Status {
+	case GatewayStatusCaptured:
+		payment.Status = PaymentStatusPaid
+	case GatewayStatusPending:
+		payment.Status = PaymentStatusPending
+	case GatewayStatusDeclined:
+		payment.Status = PaymentStatusDeclined
+	default:
+		payment.Status = PaymentStatusFailed
+	}
+}
+
+// settlePayment records the gateway's answer on a payment and, when it was
+// captured, marks the order PAID in the same transaction. A capture that
+// cannot be applied to the order is refunded.
+func settlePayment(gateway PaymentGateway, payment database.Payment, gwErr error, actorID uint) (database.Payment, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&payment).Error; err != nil {
+			return fmt.Errorf("failed to record payment")
+		}
+		if payment.Status != PaymentStatusPaid {
+			return nil
+		}
+		var order database.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error; err != nil {
+			return ErrOrderNotFound
+		}
+		note := fmt.Sprintf("payment %d captured (%s)", payment.ID, payment.TransactionID)
+		return TransitionOrder(tx, &order, OrderStatusPaid, actorID, note)
+	})
+	if err != nil {
+		if payment.Status == PaymentStatusPaid {
+			return reverseCapture(gateway, payment, err)
+		}
+		return payment, err
+	}
+	if gwErr != nil {
+		return payment, gwErr
+	}
+	switch payment.Status {
+	case PaymentStatusDeclined:
+		return payment, ErrPaymentDeclined
+	case PaymentStatusFailed:
+		return payment, fmt.Errorf("payment failed: %s", payment.GatewayMessage)
 	}
 	return payment, nil
-} 
\ No newline at end of file
+}
+
+// reverseCapture refunds a capture that could not be applied to its order
+// and records the payment as failed. When the refund fails too, the payment
+// is left PROCESSING, which blocks further attempts until it is resolved by
+// hand.
+func reverseCapture(gateway PaymentGateway, payment database.Payment, cause error) (database.Payment, error) {
+	if _, err := gateway.Refund(payment.TransactionID, payment.Amount); err != nil {
+		log.Printf("payment %d (%s) was captured but could not be applied to order %d (%v) nor refunded: %v", payment.ID, payment.TransactionID, payment.OrderID, cause, err)
+		return payment, fmt.Errorf("payment was captured but could not be applied to the order: %w", cause)
+	}
+	payment.Status = PaymentStatusFailed
+	payment.RefundedAmount = payment.Amount
+	payment.GatewayMessage = "captured but refunded, the order could not be paid: " + cause.Error()
+	if err := database.DB.Save(&payment).Error; err != nil {
+		log.Printf("payment %d (%s) was refunded but could not be recorded: %v", payment.ID, payment.TransactionID, err)
+	}
+	return payment, fmt.Errorf("payment was refunded, the order could not be paid: %w", cause)
+}
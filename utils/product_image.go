@@ -0,0 +1,314 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const (
+	defaultMaxImageSizeMB = 5
+	// maxImagePixels guards against small files that decode to huge images.
+	maxImagePixels = 40_000_000
+)
+
+var (
+	ErrUnsupportedImage     = errors.New("only JPEG, PNG and GIF images are allowed")
+	ErrImageTooLarge        = errors.New("image is too large")
+	ErrProductImageNotFound = errors.New("image not found for this product")
+	ErrInvalidImageOrder    = errors.New("image_ids must list every image of the product exactly once")
+)
+
+// ThumbnailSizes is the longest side, in pixels, of each generated thumbnail.
+// Images are never upscaled.
+var ThumbnailSizes = map[string]int{
+	"small":  150,
+	"medium": 400,
+	"large":  800,
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// MaxImageSize reads MAX_IMAGE_SIZE_MB and falls back to 5 MB.
+func MaxImageSize() int64 {
+	size, err := strconv.Atoi(os.Getenv("MAX_IMAGE_SIZE_MB"))
+	if err != nil || size <= 0 {
+		size = defaultMaxImageSizeMB
+	}
+	return int64(size) << 20
+}
+
+// SaveProductImage validates an uploaded image by sniffing its content,
+// stores it with a thumbnail per ThumbnailSizes and appends it to the
+// product's images. The first image of a product becomes its primary image.
+func SaveProductImage(productID uint, data []byte) (database.ProductImage, error) {
+	if int64(len(data)) > MaxImageSize() {
+		return database.ProductImage{}, ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return database.ProductImage{}, ErrUnsupportedImage
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return database.ProductImage{}, ErrUnsupportedImage
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return database.ProductImage{}, fmt.Errorf("%w: at most %d pixels are allowed", ErrImageTooLarge, maxImagePixels)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return database.ProductImage{}, ErrUnsupportedImage
+	}
+	storage, err := ActiveStorage()
+	if err != nil {
+		return database.ProductImage{}, err
+	}
+	name, err := randomString(12)
+	if err != nil {
+		return database.ProductImage{}, fmt.Errorf("failed to name image")
+	}
+
+	prefix := fmt.Sprintf("products/%d/%s", productID, name)
+	productImage := database.ProductImage{
+		ProductID:     productID,
+		StorageKey:    prefix + "." + ext,
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		Width:         config.Width,
+		Height:        config.Height,
+		Thumbnails:    map[string]string{},
+		ThumbnailKeys: map[string]string{},
+	}
+	saved := []string{}
+	cleanup := func() {
+		for _, key := range saved {
+			storage.Delete(key)
+		}
+	}
+	if err := storage.Save(productImage.StorageKey, contentType, bytes.NewReader(data)); err != nil {
+		return database.ProductImage{}, err
+	}
+	saved = append(saved, productImage.StorageKey)
+	productImage.URL = storage.URL(productImage.StorageKey)
+
+	// PNG keeps transparency; everything else becomes a JPEG thumbnail.
+	thumbType, thumbExt := "image/jpeg", "jpg"
+	if contentType == "image/png" {
+		thumbType, thumbExt = "image/png", "png"
+	}
+	for size, maxSide := range ThumbnailSizes {
+		var buf bytes.Buffer
+		thumbnail := resizeToFit(decoded, maxSide, thumbType == "image/jpeg")
+		if thumbType == "image/png" {
+			err = png.Encode(&buf, thumbnail)
+		} else {
+			err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			cleanup()
+			return database.ProductImage{}, fmt.Errorf("failed to generate thumbnails")
+		}
+		key := fmt.Sprintf("%s_%s.%s", prefix, size, thumbExt)
+		if err := storage.Save(key, thumbType, &buf); err != nil {
+			cleanup()
+			return database.ProductImage{}, err
+		}
+		saved = append(saved, key)
+		productImage.ThumbnailKeys[size] = key
+		productImage.Thumbnails[size] = storage.URL(key)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []database.ProductImage
+		if err := tx.Where("product_id = ?", productID).Find(&existing).Error; err != nil {
+			return err
+		}
+		productImage.IsPrimary = true
+		for _, other := range existing {
+			if other.Position >= productImage.Position {
+				productImage.Position = other.Position + 1
+			}
+			if other.IsPrimary {
+				productImage.IsPrimary = false
+			}
+		}
+		return tx.Create(&productImage).Error
+	})
+	if err != nil {
+		cleanup()
+		return database.ProductImage{}, fmt.Errorf("failed to save image")
+	}
+	return productImage, nil
+}
+
+// ProductImages returns a product's images in display order.
+func ProductImages(productID uint) ([]database.ProductImage, error) {
+	images := []database.ProductImage{}
+	err := database.DB.Where("product_id = ?", productID).Order("position, id").Find(&images).Error
+	return images, err
+}
+
+// ReorderProductImages sets the display order to the order of imageIDs.
+func ReorderProductImages(productID uint, imageIDs []uint) ([]database.ProductImage, error) {
+	images, err := ProductImages(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load images")
+	}
+	if len(imageIDs) != len(images) {
+		return nil, ErrInvalidImageOrder
+	}
+	positions := map[uint]int{}
+	for position, id := range imageIDs {
+		positions[id] = position
+	}
+	for _, productImage := range images {
+		if _, ok := positions[productImage.ID]; !ok {
+			return nil, ErrInvalidImageOrder
+		}
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for id, position := range positions {
+			if err := tx.Model(&database.ProductImage{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reorder images")
+	}
+	return ProductImages(productID)
+}
+
+// SetPrimaryProductImage makes the image the product's primary image.
+func SetPrimaryProductImage(productID, imageID uint) error {
+	var productImage database.ProductImage
+	if err := database.DB.Where("id = ? AND product_id = ?", imageID, productID).First(&productImage).Error; err != nil {
+		return ErrProductImageNotFound
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.ProductImage{}).Where("product_id = ?", productID).Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&productImage).Update("is_primary", true).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update primary image")
+	}
+	return nil
+}
+
+// DeleteProductImage removes the image and its thumbnails. When it was the
+// primary image, the next image in display order takes its place.
+func DeleteProductImage(productID, imageID uint) error {
+	var productImage database.ProductImage
+	if err := database.DB.Where("id = ? AND product_id = ?", imageID, productID).First(&productImage).Error; err != nil {
+		return ErrProductImageNotFound
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&productImage).Error; err != nil {
+			return err
+		}
+		if !productImage.IsPrimary {
+			return nil
+		}
+		var next database.ProductImage
+		if err := tx.Where("product_id = ?", productID).Order("position, id").First(&next).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete image")
+	}
+	return DeleteImageFiles([]database.ProductImage{productImage})
+}
+
+// DeleteImageFiles removes the stored files of the images.
+func DeleteImageFiles(images []database.ProductImage) error {
+	storage, err := ActiveStorage()
+	if err != nil {
+		return err
+	}
+	for _, productImage := range images {
+		if err := storage.Delete(productImage.StorageKey); err != nil {
+			return err
+		}
+		for _, key := range productImage.ThumbnailKeys {
+			if err := storage.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resizeToFit scales src down so its longest side is at most maxSide,
+// averaging the source pixels covered by each destination pixel. With opaque
+// set, transparent areas are flattened onto white for formats without alpha.
+func resizeToFit(src image.Image, maxSide int, opaque bool) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	nw, nh := w, h
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			nw, nh = maxSide, max(1, h*maxSide/w)
+		} else {
+			nw, nh = max(1, w*maxSide/h), maxSide
+		}
+	}
+	full := image.NewRGBA(image.Rect(0, 0, w, h))
+	if opaque {
+		draw.Draw(full, full.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(full, full.Bounds(), src, bounds.Min, draw.Over)
+	} else {
+		draw.Draw(full, full.Bounds(), src, bounds.Min, draw.Src)
+	}
+	if nw == w && nh == h {
+		return full
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, max((y+1)*h/nh, y*h/nh+1)
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, max((x+1)*w/nw, x*w/nw+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				offset := full.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					for i := 0; i < 4; i++ {
+						sum[i] += int(full.Pix[offset+i])
+					}
+					offset += 4
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := dst.PixOffset(x, y)
+			for i := 0; i < 4; i++ {
+				dst.Pix[offset+i] = uint8(sum[i] / count)
+			}
+		}
+	}
+	return dst
+}
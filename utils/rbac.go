@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+)
+
+// UserRoleNames returns the names of the roles assigned to a user.
+func UserRoleNames(db *gorm.DB, userID uint) ([]string, error) {
+	var names []string
+	err := db.Table("roles").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Pluck("roles.name", &names).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user roles")
+	}
+	return names, nil
+}
+
+// RolesHavePermission reports whether any of the roles grants the permission.
+func RolesHavePermission(roles []string, permission string) (bool, error) {
+	if len(roles) == 0 {
+		return false, nil
+	}
+	var count int64
+	err := database.DB.Table("role_permissions").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("roles.name IN ? AND permissions.name = ?", roles, permission).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check permissions")
+	}
+	return count > 0, nil
+}
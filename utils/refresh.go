@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"time"
+)
+
+const defaultRefreshTokenTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+)
+
+// TokenPair is returned on login, registration and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshTokenTTL reads REFRESH_TOKEN_TTL (e.g. "168h") and falls back to 7 days.
+func RefreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+// IssueTokenPair starts a new session (refresh token family) for the user.
+func IssueTokenPair(userID uint) (TokenPair, error) {
+	familyID, err := randomString(16)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("failed to create session")
+	}
+	return issueTokenPair(database.DB, userID, familyID)
+}
+
+// RotateRefreshToken exchanges a refresh token for a new pair in the same
+// family. Presenting a token that has already been exchanged revokes the
+// whole family, since it means the token was leaked.
+func RotateRefreshToken(rawToken string) (TokenPair, error) {
+	var stored database.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(rawToken)).First(&stored).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return TokenPair{}, ErrInvalidRefreshToken
+		}
+		return TokenPair{}, fmt.Errorf("failed to look up refresh token")
+	}
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		if err := RevokeSession(stored.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrRefreshTokenReused
+	}
+
+	var pair TokenPair
+	reused := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Conditional update so two concurrent refreshes cannot both succeed.
+		res := tx.Model(&database.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", stored.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = true
+			return nil
+		}
+		var err error
+		pair, err = issueTokenPair(tx, stored.UserId, stored.FamilyID)
+		return err
+	})
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("failed to rotate refresh token")
+	}
+	if reused {
+		if err := RevokeSession(stored.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrRefreshTokenReused
+	}
+	return pair, nil
+}
+
+// RevokeSession revokes every refresh token in a family, which also causes
+// access tokens carrying that session id to be rejected.
+func RevokeSession(familyID string) error {
+	err := database.DB.Model(&database.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke session")
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every session belonging to the user.
+func RevokeUserSessions(userID uint) error {
+	err := database.DB.Model(&database.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions")
+	}
+	return nil
+}
+
+// IsSessionRevoked reports whether the refresh token family has been revoked.
+func IsSessionRevoked(familyID string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&database.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NOT NULL", familyID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func issueTokenPair(db *gorm.DB, userID uint, familyID string) (TokenPair, error) {
+	rawToken, err := randomString(32)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("failed to generate refresh token")
+	}
+	refreshToken := database.RefreshToken{
+		UserId:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL()),
+	}
+	if err := db.Create(&refreshToken).Error; err != nil {
+		return TokenPair{}, fmt.Errorf("failed to store refresh token")
+	}
+	roles, err := UserRoleNames(db, userID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	accessToken, err := GenerateToken(userID, familyID, roles)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{AccessToken: accessToken, RefreshToken: rawToken}, nil
+}
+
+func hashToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
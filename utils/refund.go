@@ -0,0 +1,362 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+)
+
+// Refund statuses persisted on database.Refund.
+// A refund is PENDING from when it is claimed until the gateway has
+// answered and the answer is recorded.
+const (
+	RefundStatusPending   = "PENDING"
+	RefundStatusSucceeded = "SUCCEEDED"
+	RefundStatusFailed    = "FAILED"
+)
+
+var (
+	ErrOrderNotRefundable  = errors.New("order has no captured payment to refund")
+	ErrNothingToRefund     = errors.New("nothing left to refund on this order")
+	ErrRefundExceedsAmount = errors.New("refund exceeds the amount left to refund")
+	ErrInvalidRefundItem   = errors.New("invalid refund item")
+	ErrRefundFailed        = errors.New("payment gateway rejected the refund")
+	ErrRefundCurrency      = errors.New("refund must be in the currency of the payment")
+	ErrRefundNotRecorded   = errors.New("refund was processed by the gateway but could not be recorded")
+)
+
+// RefundLine selects a quantity of an order item to refund.
+type RefundLine struct {
+	OrderItemID uint `json:"order_item_id" example:"1"`
+	Quantity    int  `json:"quantity" example:"1"`
+}
+
+// RefundOrderInFull refunds everything that has not been refunded yet and
+// restocks every remaining item.
+func RefundOrderInFull(orderID uint, actorID uint, reason string) (database.Refund, error) {
+	return refundOrder(orderID, actorID, reason, refundRequest{full: true})
+}
+
+// RefundOrderPartially refunds the given lines, restocking them. When amount
+// is zero it is computed from the lines' prices; an amount without lines is a
+// goodwill refund that does not touch stock.
+func RefundOrderPartially(orderID uint, actorID uint, reason string, lines []RefundLine, amount database.Money) (database.Refund, error) {
+	if len(lines) == 0 && !amount.IsPositive() {
+		return database.Refund{}, ErrInvalidRefundItem
+	}
+	return refundOrder(orderID, actorID, reason, refundRequest{lines: lines, amount: amount})
+}
+
+// CapturedPayment returns the successful payment of an order.
+func CapturedPayment(db *gorm.DB, orderID uint) (database.Payment, error) {
+	var payment database.Payment
+	err := db.Where("order_id = ? AND status = ?", orderID, PaymentStatusPaid).Order("id desc").First(&payment).Error
+	return payment, err
+}
+
+// refundRequest is what refundOrder gives back: the lines and/or amount, or
+// everything left when full is set.
+type refundRequest struct {
+	lines  []RefundLine
+	amount database.Money
+	full   bool
+	// cancel leaves the order CANCELLED rather than refunded.
+	cancel bool
+	// awaitGoods leaves the items out of stock until they are sent back.
+	awaitGoods bool
+	// check runs with the order locked, before the refund is claimed.
+	check func(tx *gorm.DB, order database.Order) error
+	// record runs in the transaction that records the refund.
+	record func(tx *gorm.DB, order *database.Order, refund database.Refund) error
+}
+
+// refundOrder claims the refund before calling the gateway: with the order
+// and payment rows locked it works out what is left to refund and saves the
+// refund PENDING, counting its amount and items as refunded, so concurrent
+// refunds cannot give the same money back twice. The refund is settled once
+// the gateway answers; one the gateway accepted but that could not be
+// settled stays PENDING rather than being lost.
+func refundOrder(orderID uint, actorID uint, reason string, request refundRequest) (database.Refund, error) {
+	gateway, err := ActivePaymentGateway()
+	if err != nil {
+		return database.Refund{}, err
+	}
+	var order database.Order
+	var payment database.Payment
+	var refund database.Refund
+	var targetStatus string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			return ErrOrderNotFound
+		}
+		if request.check != nil {
+			if err := request.check(tx, order); err != nil {
+				return err
+			}
+		}
+		var err error
+		payment, err = CapturedPayment(tx.Clauses(clause.Locking{Strength: "UPDATE"}), order.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotRefundable
+			}
+			return fmt.Errorf("failed to load payment")
+		}
+		refund, targetStatus, err = prepareRefund(tx, order, payment, reason, request)
+		if err != nil {
+			return err
+		}
+		refund.CreatedBy = actorID
+		if err := tx.Create(&refund).Error; err != nil {
+			return fmt.Errorf("failed to record refund")
+		}
+		return adjustRefundedAmount(tx, payment.ID, refund.Amount.Amount)
+	})
+	if err != nil {
+		return database.Refund{}, err
+	}
+
+	resp, gwErr := gateway.Refund(payment.TransactionID, refund.Amount)
+	refund.TransactionID = resp.TransactionID
+	refund.GatewayCode = resp.Code
+	refund.GatewayMessage = resp.Message
+	if gwErr != nil {
+		refund.Status = RefundStatusFailed
+		refund.GatewayMessage = gwErr.Error()
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("refund_id = ?", refund.ID).Delete(&database.RefundItem{}).Error; err != nil {
+				return err
+			}
+			if err := saveRefundOutcome(tx, refund); err != nil {
+				return err
+			}
+			return adjustRefundedAmount(tx, payment.ID, -refund.Amount.Amount)
+		})
+		if err != nil {
+			log.Printf("refund %d was rejected by the gateway but could not be recorded: %v", refund.ID, err)
+			return refund, fmt.Errorf("refund was rejected by the gateway but could not be recorded, refund %d is left PENDING", refund.ID)
+		}
+		refund.Items = nil
+		return refund, fmt.Errorf("%w: %s", ErrRefundFailed, gwErr.Error())
+	}
+	refund.Status = RefundStatusSucceeded
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveRefundOutcome(tx, refund); err != nil {
+			return err
+		}
+		if !request.awaitGoods {
+			for _, item := range refund.Items {
+				_, err := MoveStock(tx, StockChange{
+					ProductID: item.ProductId,
+					VariantID: item.VariantId,
+					Type:      database.StockMovementReturn,
+					Quantity:  item.Quantity,
+					Reason:    "refunded",
+					Reference: fmt.Sprintf("refund:%d", refund.ID),
+					ActorID:   actorID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if targetStatus != "" {
+			note := fmt.Sprintf("refund %d: %s", refund.ID, reason)
+			if err := TransitionOrder(tx, &order, targetStatus, actorID, note); err != nil {
+				return err
+			}
+		}
+		if err := setOrderRefundStatus(tx, &order, orderRefundStatus(payment, refund.Amount)); err != nil {
+			return err
+		}
+		if request.record != nil {
+			return request.record(tx, &order, refund)
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the gateway's answer on the PENDING refund so it can be
+		// settled by hand; its amount and items stay counted as refunded.
+		refund.Status = RefundStatusPending
+		if saveErr := saveRefundOutcome(database.DB, refund); saveErr != nil {
+			log.Printf("failed to save the gateway response of refund %d: %v", refund.ID, saveErr)
+		}
+		log.Printf("refund %d was processed by the gateway (%s) but could not be recorded: %v", refund.ID, refund.TransactionID, err)
+		return refund, fmt.Errorf("%w, refund %d is left PENDING", ErrRefundNotRecorded, refund.ID)
+	}
+	return refund, nil
+}
+
+// prepareRefund works out the refund of the request against what is left
+// to refund on the payment, and the status the order moves to, if any. A
+// partial refund leaves the order's status alone.
+func prepareRefund(tx *gorm.DB, order database.Order, payment database.Payment, reason string, request refundRequest) (database.Refund, string, error) {
+	lines, amount, full := request.lines, request.amount, request.full
+	if !amount.SameCurrency(payment.Amount) {
+		return database.Refund{}, "", ErrRefundCurrency
+	}
+	remaining := payment.Amount.Sub(payment.RefundedAmount)
+	if !remaining.IsPositive() {
+		return database.Refund{}, "", ErrNothingToRefund
+	}
+
+	var orderItems []database.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&orderItems).Error; err != nil {
+		return database.Refund{}, "", fmt.Errorf("failed to load order items")
+	}
+	refunded, err := refundedQuantities(tx, order.ID)
+	if err != nil {
+		return database.Refund{}, "", err
+	}
+	itemsByID := map[uint]database.OrderItem{}
+	for _, item := range orderItems {
+		itemsByID[item.ID] = item
+	}
+	if full {
+		lines = nil
+		for _, item := range orderItems {
+			if left := item.Quantity - refunded[item.ID]; left > 0 {
+				lines = append(lines, RefundLine{OrderItemID: item.ID, Quantity: left})
+			}
+		}
+	}
+
+	var refundItems []database.RefundItem
+	linesTotal := database.NewMoney(0, payment.Amount.Currency)
+	for _, line := range lines {
+		item, ok := itemsByID[line.OrderItemID]
+		if !ok || line.Quantity <= 0 {
+			return database.Refund{}, "", ErrInvalidRefundItem
+		}
+		if line.Quantity > item.Quantity-refunded[item.ID] {
+			return database.Refund{}, "", fmt.Errorf("%w: only %d of order item %d can still be refunded", ErrInvalidRefundItem, item.Quantity-refunded[item.ID], item.ID)
+		}
+		refunded[item.ID] += line.Quantity
+		// Give back what was paid for the units: their price less their
+		// share of the order's discount, plus their share of the tax when
+		// it was added to the price.
+		lineAmount := item.Price.Mul(line.Quantity).Sub(item.Discount.MulRatio(int64(line.Quantity), int64(item.Quantity)))
+		if !order.TaxInclusive {
+			lineAmount = lineAmount.Add(item.Tax.MulRatio(int64(line.Quantity), int64(item.Quantity)))
+		}
+		linesTotal = linesTotal.Add(lineAmount)
+		refundItems = append(refundItems, database.RefundItem{
+			OrderItemID: item.ID,
+			ProductId:   item.ProductId,
+			VariantId:   item.VariantId,
+			Quantity:    line.Quantity,
+			Amount:      lineAmount,
+		})
+	}
+	switch {
+	case full:
+		amount = remaining
+	case !amount.IsPositive():
+		amount = linesTotal
+	}
+	if !amount.IsPositive() {
+		return database.Refund{}, "", ErrNothingToRefund
+	}
+	if amount.GreaterThan(remaining) {
+		return database.Refund{}, "", ErrRefundExceedsAmount
+	}
+
+	targetStatus := ""
+	if orderRefundStatus(payment, amount) == OrderRefundFull {
+		targetStatus = OrderStatusRefunded
+	}
+	if request.cancel {
+		targetStatus = OrderStatusCancelled
+	}
+	if targetStatus != "" && !CanTransitionOrder(order.Status, targetStatus) {
+		return database.Refund{}, "", &InvalidTransitionError{From: order.Status, To: targetStatus}
+	}
+	refund := database.Refund{
+		PaymentID: payment.ID,
+		OrderID:   order.ID,
+		Amount:    amount,
+		Reason:    reason,
+		Status:    RefundStatusPending,
+		Items:     refundItems,
+	}
+	return refund, targetStatus, nil
+}
+
+// orderRefundStatus is the refund state of an order once amount more of its
+// payment has been refunded.
+func orderRefundStatus(payment database.Payment, amount database.Money) string {
+	if payment.RefundedAmount.Add(amount).LessThan(payment.Amount) {
+		return OrderRefundPartial
+	}
+	return OrderRefundFull
+}
+
+// setOrderRefundStatus records the refund state of an order. A fully
+// refunded order stays so when a smaller refund settles after it.
+func setOrderRefundStatus(tx *gorm.DB, order *database.Order, status string) error {
+	res := tx.Model(&database.Order{}).Where("id = ? AND refund_status <> ?", order.ID, OrderRefundFull).Update("refund_status", status)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update order")
+	}
+	if res.RowsAffected > 0 {
+		order.RefundStatus = status
+	}
+	return nil
+}
+
+// adjustRefundedAmount adds delta minor units to what has been refunded of
+// the payment.
+func adjustRefundedAmount(tx *gorm.DB, paymentID uint, delta int64) error {
+	err := tx.Model(&database.Payment{}).Where("id = ?", paymentID).
+		Update("refunded_amount_amount", gorm.Expr("refunded_amount_amount + ?", delta)).Error
+	if err != nil {
+		return fmt.Errorf("failed to update payment")
+	}
+	return nil
+}
+
+// saveRefundOutcome stores the status and gateway response of a refund that
+// was claimed PENDING.
+func saveRefundOutcome(tx *gorm.DB, refund database.Refund) error {
+	res := tx.Model(&database.Refund{}).Where("id = ? AND status = ?", refund.ID, RefundStatusPending).
+		Updates(map[string]interface{}{
+			"status":          refund.Status,
+			"transaction_id":  refund.TransactionID,
+			"gateway_code":    refund.GatewayCode,
+			"gateway_message": refund.GatewayMessage,
+		})
+	if res.Error != nil {
+		return fmt.Errorf("failed to update refund")
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("refund %d is no longer pending", refund.ID)
+	}
+	return nil
+}
+
+// refundedQuantities sums the quantities of each order item that have been
+// refunded or are being refunded.
+func refundedQuantities(db *gorm.DB, orderID uint) (map[uint]int, error) {
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err := db.Model(&database.RefundItem{}).
+		Select("refund_items.order_item_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.order_id = ? AND refunds.status IN ?", orderID, []string{RefundStatusPending, RefundStatusSucceeded}).
+		Group("refund_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous refunds")
+	}
+	quantities := map[uint]int{}
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"testing"
+)
+
+func TestOrderRefundStatus(t *testing.T) {
+	tests := []struct {
+		paid, refunded, amount int64
+		want                   string
+	}{
+		{paid: 10000, refunded: 0, amount: 2500, want: OrderRefundPartial},
+		{paid: 10000, refunded: 2500, amount: 2500, want: OrderRefundPartial},
+		{paid: 10000, refunded: 0, amount: 9999, want: OrderRefundPartial},
+		{paid: 10000, refunded: 0, amount: 10000, want: OrderRefundFull},
+		{paid: 10000, refunded: 7500, amount: 2500, want: OrderRefundFull},
+		{paid: 10000, refunded: 9999, amount: 1, want: OrderRefundFull},
+	}
+	for _, tt := range tests {
+		payment := database.Payment{Amount: database.NewMoney(tt.paid, "USD"), RefundedAmount: database.NewMoney(tt.refunded, "USD")}
+		if got := orderRefundStatus(payment, database.NewMoney(tt.amount, "USD")); got != tt.want {
+			t.Errorf("refunding %d more of %d with %d refunded = %s, want %s", tt.amount, tt.paid, tt.refunded, got, tt.want)
+		}
+	}
+}
+
+func TestOrderRefundStatusUnsetRefundedAmount(t *testing.T) {
+	// Payments made before refunds were tracked have no refunded amount.
+	payment := database.Payment{Amount: database.NewMoney(10000, "USD")}
+	if got := orderRefundStatus(payment, database.NewMoney(10000, "USD")); got != OrderRefundFull {
+		t.Errorf("refunding all of a payment with no refunded amount = %s, want %s", got, OrderRefundFull)
+	}
+	if got := orderRefundStatus(payment, database.NewMoney(100, "USD")); got != OrderRefundPartial {
+		t.Errorf("refunding part of a payment with no refunded amount = %s, want %s", got, OrderRefundPartial)
+	}
+}
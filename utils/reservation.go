@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+const (
+	defaultReservationTTL           = 15 * time.Minute
+	defaultReservationSweepInterval = time.Minute
+)
+
+var ErrInsufficientStock = errors.New("product in stock is not enough")
+
+// ReservationTTL reads RESERVATION_TTL (e.g. "15m") and falls back to 15 minutes.
+func ReservationTTL() time.Duration {
+	return durationFromEnv("RESERVATION_TTL", defaultReservationTTL)
+}
+
+// ReserveCartItem holds stock for the whole quantity of a cart item until
+// ReservationTTL from now, replacing any earlier hold of the same item. The
+// stock row is locked so concurrent reservations cannot hold more than is on
+// hand. It must run inside a transaction.
+func ReserveCartItem(tx *gorm.DB, cartItem database.CartItem) error {
+	onHand, err := lockStock(tx, cartItem.ProductId, cartItem.VariantId)
+	if err != nil {
+		return err
+	}
+	held, err := reservedQuantity(tx, cartItem.ProductId, cartItem.VariantId, cartItem.ID)
+	if err != nil {
+		return err
+	}
+	if available := onHand - held; available < cartItem.Quantity {
+		return fmt.Errorf("%w: %d available", ErrInsufficientStock, max(available, 0))
+	}
+	reservation := database.StockReservation{CartItemID: cartItem.ID}
+	if err := tx.Where("cart_item_id = ?", cartItem.ID).First(&reservation).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("failed to load reservation")
+	}
+	reservation.CartID = cartItem.CartId
+	reservation.ProductID = cartItem.ProductId
+	reservation.VariantID = cartItem.VariantId
+	reservation.Quantity = cartItem.Quantity
+	reservation.ExpiresAt = time.Now().Add(ReservationTTL())
+	if err := tx.Save(&reservation).Error; err != nil {
+		return fmt.Errorf("failed to reserve stock")
+	}
+	return nil
+}
+
+// ShrinkReservation lowers the quantity held for a cart item without
+// extending its expiry.
+func ShrinkReservation(db *gorm.DB, cartItemID uint, quantity int) error {
+	var reservation database.StockReservation
+	if err := db.Where("cart_item_id = ?", cartItemID).First(&reservation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to load reservation")
+	}
+	if reservation.Quantity <= quantity {
+		return nil
+	}
+	if err := db.Model(&reservation).Update("quantity", quantity).Error; err != nil {
+		return fmt.Errorf("failed to update reservation")
+	}
+	return recordReservationRelease(db, reservation, reservation.Quantity-quantity, "removed from cart")
+}
+
+// ReleaseReservation drops the hold of a cart item.
+func ReleaseReservation(db *gorm.DB, cartItemID uint) error {
+	var reservation database.StockReservation
+	if err := db.Where("cart_item_id = ?", cartItemID).First(&reservation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to load reservation")
+	}
+	if err := db.Delete(&reservation).Error; err != nil {
+		return fmt.Errorf("failed to release reservation")
+	}
+	return recordReservationRelease(db, reservation, reservation.Quantity, "removed from cart")
+}
+
+// ReleaseCartReservations drops every hold of a cart once its stock has
+// been taken by an order, so nothing is recorded as released.
+func ReleaseCartReservations(db *gorm.DB, cartID uint) error {
+	return db.Where("cart_id = ?", cartID).Delete(&database.StockReservation{}).Error
+}
+
+// CartItemReservation returns the active hold of a cart item, if any.
+func CartItemReservation(db *gorm.DB, cartItemID uint) (*database.StockReservation, error) {
+	var reservation database.StockReservation
+	err := db.Where("cart_item_id = ? AND expires_at > ?", cartItemID, time.Now()).First(&reservation).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// AvailableForCartItem is the stock the cart item can still be ordered
+// from: what is on hand minus what other carts hold.
+func AvailableForCartItem(db *gorm.DB, cartItem database.CartItem, onHand int) (int, error) {
+	held, err := reservedQuantity(db, cartItem.ProductId, cartItem.VariantId, cartItem.ID)
+	if err != nil {
+		return 0, err
+	}
+	return max(onHand-held, 0), nil
+}
+
+// TakeStock removes quantity from on-hand stock when an order is placed and
+// records the sale in the stock ledger. The stock row is locked and holds of
+// other carts are respected; the cart item's own hold (if still active) is
+// what it is taking. It must run inside a transaction.
+func TakeStock(tx *gorm.DB, cartItem database.CartItem, orderID uint, actorID uint) error {
+	onHand, err := lockStock(tx, cartItem.ProductId, cartItem.VariantId)
+	if err != nil {
+		return err
+	}
+	held, err := reservedQuantity(tx, cartItem.ProductId, cartItem.VariantId, cartItem.ID)
+	if err != nil {
+		return err
+	}
+	if onHand-held < cartItem.Quantity {
+		return ErrInsufficientStock
+	}
+	_, err = MoveStock(tx, StockChange{
+		ProductID: cartItem.ProductId,
+		VariantID: cartItem.VariantId,
+		Type:      database.StockMovementSale,
+		Quantity:  -cartItem.Quantity,
+		Reason:    "order placed",
+		Reference: fmt.Sprintf("order:%d", orderID),
+		ActorID:   actorID,
+	})
+	return err
+}
+
+// SetAvailability fills AvailableQty of the products, and of their variants
+// when loaded, as on-hand stock minus active reservations.
+func SetAvailability(products []database.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	held, err := reservedByItem(ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].AvailableQty = max(products[i].StockQty-held[[2]uint{products[i].ID, 0}], 0)
+		for j := range products[i].Variants {
+			variant := &products[i].Variants[j]
+			variant.AvailableQty = max(variant.StockQty-held[[2]uint{products[i].ID, variant.ID}], 0)
+		}
+	}
+	return nil
+}
+
+// SetVariantAvailability fills AvailableQty of the variants.
+func SetVariantAvailability(variants []database.ProductVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(variants))
+	for _, variant := range variants {
+		ids = append(ids, variant.ProductID)
+	}
+	held, err := reservedByItem(ids)
+	if err != nil {
+		return err
+	}
+	for i := range variants {
+		variants[i].AvailableQty = max(variants[i].StockQty-held[[2]uint{variants[i].ProductID, variants[i].ID}], 0)
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations deletes holds whose expiry has passed and
+// records their release. Expired holds are already ignored by every stock
+// check, so this mostly keeps the table and the ledger tidy.
+func ReleaseExpiredReservations() (int64, error) {
+	var expired []database.StockReservation
+	if err := database.DB.Where("expires_at <= ?", time.Now()).Find(&expired).Error; err != nil {
+		return 0, err
+	}
+	var released int64
+	for _, reservation := range expired {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			// A concurrent checkout or cart change may have removed it already.
+			res := tx.Where("id = ? AND expires_at <= ?", reservation.ID, time.Now()).Delete(&database.StockReservation{})
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			released++
+			return recordReservationRelease(tx, reservation, reservation.Quantity, "reservation expired")
+		})
+		if err != nil {
+			return released, err
+		}
+	}
+	return released, nil
+}
+
+// StartReservationSweeper releases expired holds every
+// RESERVATION_SWEEP_INTERVAL (1 minute by default) for the life of the process.
+func StartReservationSweeper() {
+	interval := durationFromEnv("RESERVATION_SWEEP_INTERVAL", defaultReservationSweepInterval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if released, err := ReleaseExpiredReservations(); err != nil {
+				log.Println("failed to release expired stock reservations:", err)
+			} else if released > 0 {
+				log.Printf("released %d expired stock reservations", released)
+			}
+		}
+	}()
+}
+
+func stockQuery(db *gorm.DB, productID, variantID uint) *gorm.DB {
+	if variantID != 0 {
+		return db.Model(&database.ProductVariant{}).Where("id = ?", variantID)
+	}
+	return db.Model(&database.Product{}).Where("id = ?", productID)
+}
+
+// lockStock reads on-hand stock with SELECT ... FOR UPDATE.
+func lockStock(tx *gorm.DB, productID, variantID uint) (int, error) {
+	var quantities []int
+	err := stockQuery(tx, productID, variantID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Pluck("stock_qty", &quantities).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock product stock")
+	}
+	if len(quantities) == 0 {
+		return 0, ErrProductNotFound
+	}
+	return quantities[0], nil
+}
+
+// reservedQuantity sums the active holds on a product or variant, leaving
+// out the hold of excludeCartItemID.
+func reservedQuantity(db *gorm.DB, productID, variantID, excludeCartItemID uint) (int, error) {
+	var held int
+	err := db.Model(&database.StockReservation{}).
+		Where("product_id = ? AND variant_id = ? AND expires_at > ? AND cart_item_id <> ?", productID, variantID, time.Now(), excludeCartItemID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&held).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load stock reservations")
+	}
+	return held, nil
+}
+
+// reservedByItem sums active holds per product and variant id.
+func reservedByItem(productIDs []uint) (map[[2]uint]int, error) {
+	var rows []struct {
+		ProductID uint
+		VariantID uint
+		Quantity  int
+	}
+	err := database.DB.Model(&database.StockReservation{}).
+		Select("product_id, variant_id, SUM(quantity) AS quantity").
+		Where("product_id IN ? AND expires_at > ?", productIDs, time.Now()).
+		Group("product_id, variant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stock reservations")
+	}
+	held := map[[2]uint]int{}
+	for _, row := range rows {
+		held[[2]uint{row.ProductID, row.VariantID}] = row.Quantity
+	}
+	return held, nil
+}
@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Return request statuses: a REQUESTED return is APPROVED (and refunded) or
+// REJECTED, and an approved one is RECEIVED once its goods are back. It is
+// APPROVING while its refund goes through the payment gateway.
+const (
+	ReturnStatusRequested = "REQUESTED"
+	ReturnStatusApproving = "APPROVING"
+	ReturnStatusApproved  = "APPROVED"
+	ReturnStatusRejected  = "REJECTED"
+	ReturnStatusReceived  = "RECEIVED"
+)
+
+const defaultReturnWindowDays = 30
+
+var (
+	ErrReturnNotFound      = errors.New("return request not found")
+	ErrOrderNotReturnable  = errors.New("only delivered orders can be returned")
+	ErrReturnWindowClosed  = errors.New("the return window of this order has closed")
+	ErrInvalidReturnItem   = errors.New("invalid return item")
+	ErrReturnStatusChanged = errors.New("return request is not in a status that allows this")
+)
+
+// ReturnLine is a quantity of an order item a customer wants to send back.
+type ReturnLine struct {
+	OrderItemID uint   `json:"order_item_id" example:"1"`
+	Quantity    int    `json:"quantity" example:"1"`
+	Reason      string `json:"reason" example:"too small"`
+}
+
+// ReturnWindow reads RETURN_WINDOW_DAYS, how long after delivery items can
+// be returned, and falls back to 30 days.
+func ReturnWindow() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("RETURN_WINDOW_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultReturnWindowDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RequestReturn records a user's request to return lines of a delivered
+// order within the return window. Items can only be returned once, and not
+// after they were refunded.
+func RequestReturn(order database.Order, userID uint, reason string, lines []ReturnLine) (database.ReturnRequest, error) {
+	request := database.ReturnRequest{OrderID: order.ID, UserID: userID, Status: ReturnStatusRequested, Reason: strings.TrimSpace(reason)}
+	if order.Status != OrderStatusDelivered {
+		return request, ErrOrderNotReturnable
+	}
+	var delivered database.OrderStatusHistory
+	err := database.DB.Where("order_id = ? AND to_status = ?", order.ID, OrderStatusDelivered).Order("created_at desc").First(&delivered).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return request, ErrOrderNotReturnable
+		}
+		return request, fmt.Errorf("failed to load order history")
+	}
+	if time.Since(delivered.CreatedAt) > ReturnWindow() {
+		return request, fmt.Errorf("%w, it closed on %s", ErrReturnWindowClosed, delivered.CreatedAt.Add(ReturnWindow()).Format("2006-01-02"))
+	}
+	if len(lines) == 0 {
+		return request, fmt.Errorf("%w: items are required", ErrInvalidReturnItem)
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the order so concurrent requests cannot return an item twice.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&database.Order{}, order.ID).Error; err != nil {
+			return fmt.Errorf("failed to load order")
+		}
+		items, returnable, err := returnableQuantities(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		itemsByID := map[uint]database.OrderItem{}
+		for _, item := range items {
+			itemsByID[item.ID] = item
+		}
+		for _, line := range lines {
+			item, ok := itemsByID[line.OrderItemID]
+			if !ok || line.Quantity <= 0 {
+				return fmt.Errorf("%w: order item %d", ErrInvalidReturnItem, line.OrderItemID)
+			}
+			if line.Quantity > returnable[item.ID] {
+				return fmt.Errorf("%w: only %d of order item %d can be returned", ErrInvalidReturnItem, returnable[item.ID], item.ID)
+			}
+			returnable[item.ID] -= line.Quantity
+			request.Items = append(request.Items, database.ReturnItem{
+				OrderItemID: item.ID,
+				ProductId:   item.ProductId,
+				VariantId:   item.VariantId,
+				Quantity:    line.Quantity,
+				Reason:      strings.TrimSpace(line.Reason),
+			})
+		}
+		if err := tx.Create(&request).Error; err != nil {
+			return fmt.Errorf("failed to save return request")
+		}
+		return nil
+	})
+	return request, err
+}
+
+// ApproveReturn approves a requested return and refunds its items through
+// the payment gateway. They are restocked when they are received. The
+// return is claimed APPROVING before the gateway is called, so it cannot be
+// refunded twice, and goes back to REQUESTED when the refund fails.
+func ApproveReturn(returnID uint, actorID uint, note string) (database.ReturnRequest, error) {
+	request, err := LoadReturn(database.DB, returnID)
+	if err != nil {
+		return request, err
+	}
+	if err := moveReturn(database.DB, returnID, ReturnStatusRequested, ReturnStatusApproving, nil); err != nil {
+		return request, err
+	}
+	lines := make([]RefundLine, 0, len(request.Items))
+	for _, item := range request.Items {
+		lines = append(lines, RefundLine{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+	reason := fmt.Sprintf("return %d", request.ID)
+	if request.Reason != "" {
+		reason += ": " + request.Reason
+	}
+	_, err = refundOrder(request.OrderID, actorID, reason, refundRequest{
+		lines:      lines,
+		awaitGoods: true,
+		record: func(tx *gorm.DB, _ *database.Order, refund database.Refund) error {
+			return decideReturn(tx, request.ID, ReturnStatusApproving, ReturnStatusApproved, actorID, note, &refund.ID)
+		},
+	})
+	if err != nil {
+		// A refund the gateway accepted is left PENDING for a person to
+		// settle, and so is the return.
+		if !errors.Is(err, ErrRefundNotRecorded) {
+			if releaseErr := moveReturn(database.DB, request.ID, ReturnStatusApproving, ReturnStatusRequested, nil); releaseErr != nil {
+				log.Printf("failed to put return %d back to requested: %v", request.ID, releaseErr)
+			}
+		}
+		return request, err
+	}
+	return LoadReturn(database.DB, request.ID)
+}
+
+// RejectReturn turns down a requested return.
+func RejectReturn(returnID uint, actorID uint, note string) (database.ReturnRequest, error) {
+	if err := decideReturn(database.DB, returnID, ReturnStatusRequested, ReturnStatusRejected, actorID, note, nil); err != nil {
+		return database.ReturnRequest{}, err
+	}
+	return LoadReturn(database.DB, returnID)
+}
+
+// ReceiveReturn records that the goods of an approved return came back and
+// puts them back in stock.
+func ReceiveReturn(returnID uint, actorID uint) (database.ReturnRequest, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var request database.ReturnRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, returnID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReturnNotFound
+			}
+			return fmt.Errorf("failed to load return request")
+		}
+		if request.Status != ReturnStatusApproved {
+			return ErrReturnStatusChanged
+		}
+		var items []database.ReturnItem
+		if err := tx.Where("return_request_id = ?", request.ID).Find(&items).Error; err != nil {
+			return fmt.Errorf("failed to load return items")
+		}
+		for _, item := range items {
+			_, err := MoveStock(tx, StockChange{
+				ProductID: item.ProductId,
+				VariantID: item.VariantId,
+				Type:      database.StockMovementReturn,
+				Quantity:  item.Quantity,
+				Reason:    "return received",
+				Reference: fmt.Sprintf("return:%d", request.ID),
+				ActorID:   actorID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		return tx.Model(&request).Updates(map[string]interface{}{
+			"status":      ReturnStatusReceived,
+			"received_by": actorID,
+			"received_at": now,
+		}).Error
+	})
+	if err != nil {
+		return database.ReturnRequest{}, err
+	}
+	return LoadReturn(database.DB, returnID)
+}
+
+// LoadReturn loads a return request with its items.
+func LoadReturn(db *gorm.DB, returnID uint) (database.ReturnRequest, error) {
+	var request database.ReturnRequest
+	if err := db.Preload("Items").First(&request, returnID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return request, ErrReturnNotFound
+		}
+		return request, fmt.Errorf("failed to load return request")
+	}
+	return request, nil
+}
+
+// decideReturn records the decision on a return, moving it from one status
+// to another.
+func decideReturn(db *gorm.DB, returnID uint, from string, to string, actorID uint, note string, refundID *uint) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"note":       strings.TrimSpace(note),
+		"decided_by": actorID,
+		"decided_at": now,
+	}
+	if refundID != nil {
+		updates["refund_id"] = *refundID
+	}
+	return moveReturn(db, returnID, from, to, updates)
+}
+
+// moveReturn moves a return from one status to another along with the
+// other updates. It only applies while the return is still in from, so
+// concurrent changes cannot both succeed.
+func moveReturn(db *gorm.DB, returnID uint, from string, to string, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	res := db.Model(&database.ReturnRequest{}).Where("id = ? AND status = ?", returnID, from).Updates(updates)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update return request")
+	}
+	if res.RowsAffected == 0 {
+		var count int64
+		if err := db.Model(&database.ReturnRequest{}).Where("id = ?", returnID).Count(&count).Error; err == nil && count == 0 {
+			return ErrReturnNotFound
+		}
+		return ErrReturnStatusChanged
+	}
+	return nil
+}
+
+// returnableQuantities returns the order's items and how many of each can
+// still be returned: neither refunded nor in a return awaiting a decision or
+// being approved.
+func returnableQuantities(db *gorm.DB, orderID uint) ([]database.OrderItem, map[uint]int, error) {
+	var items []database.OrderItem
+	if err := db.Where("order_id = ?", orderID).Order("id asc").Find(&items).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load order items")
+	}
+	var requested []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err := db.Model(&database.ReturnItem{}).
+		Select("return_items.order_item_id, SUM(return_items.quantity) AS quantity").
+		Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id").
+		Where("return_requests.order_id = ? AND return_requests.status IN ?", orderID, []string{ReturnStatusRequested, ReturnStatusApproving}).
+		Group("return_items.order_item_id").
+		Scan(&requested).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load return requests")
+	}
+	refunded, err := refundedQuantities(db, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	returnable := map[uint]int{}
+	for _, item := range items {
+		returnable[item.ID] = item.Quantity - refunded[item.ID]
+	}
+	for _, row := range requested {
+		returnable[row.OrderItemID] -= row.Quantity
+	}
+	return items, returnable, nil
+}
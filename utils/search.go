@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"errors"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var ErrEmptySearch = errors.New("search query must contain at least one word")
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+	// ts_headline marks matches with these private-use characters, removed
+	// from the text beforehand, which are turned into tags once the text
+	// around them has been HTML-escaped.
+	headlineStart = "\ue000"
+	headlineStop  = "\ue001"
+)
+
+// ProductSearchResult is a product matched by SearchProducts with its
+// relevance and the matched terms wrapped in <mark> tags. NameHighlight and
+// Snippet are HTML: the product's text in them is escaped.
+type ProductSearchResult struct {
+	database.Product `gorm:"embedded"`
+	Rank             float64 `json:"rank" example:"0.6079"`
+	NameHighlight    string  `json:"name_highlight" example:"<mark>iPhone</mark> 15"`
+	Snippet          string  `json:"snippet" example:"Latest <mark>iPhone</mark> model with advanced features"`
+}
+
+// SearchProducts runs a ranked full-text search over product names and
+// descriptions. Every word must match and is matched as a prefix, so "iph"
+// finds "iPhone". PostgreSQL uses the search_vector column; other dialects
+// fall back to LIKE matching.
+func SearchProducts(query string, page, pageSize int) ([]ProductSearchResult, int64, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, 0, ErrEmptySearch
+	}
+	if database.DB.Dialector.Name() == "postgres" {
+		return searchProductsPostgres(terms, page, pageSize)
+	}
+	return searchProductsLike(terms, page, pageSize)
+}
+
+func searchProductsPostgres(terms []string, page, pageSize int) ([]ProductSearchResult, int64, error) {
+	prefixes := make([]string, 0, len(terms))
+	for _, term := range terms {
+		prefixes = append(prefixes, term+":*")
+	}
+	tsQuery := strings.Join(prefixes, " & ")
+	base := database.DB.Table("products, to_tsquery('english', ?) AS query", tsQuery).
+		Where("products.search_vector @@ query")
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	results := []ProductSearchResult{}
+	err := base.Session(&gorm.Session{}).
+		Select(`products.*,
+			ts_rank(products.search_vector, query) AS rank,
+			ts_headline('english', translate(products.name, ?, ''), query, ?) AS name_highlight,
+			ts_headline('english', translate(products.description, ?, ''), query, ?) AS snippet`,
+			headlineStart+headlineStop,
+			"StartSel="+headlineStart+", StopSel="+headlineStop+", HighlightAll=true",
+			headlineStart+headlineStop,
+			"StartSel="+headlineStart+", StopSel="+headlineStop+", MaxFragments=2, MaxWords=25, MinWords=8").
+		Order("rank DESC, products.id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&results).Error
+	for i := range results {
+		results[i].NameHighlight = escapeHeadline(results[i].NameHighlight)
+		results[i].Snippet = escapeHeadline(results[i].Snippet)
+	}
+	return results, total, err
+}
+
+// escapeHeadline HTML-escapes a ts_headline result and turns its match
+// markers into <mark> tags.
+func escapeHeadline(headline string) string {
+	return strings.NewReplacer(headlineStart, highlightStart, headlineStop, highlightStop).Replace(html.EscapeString(headline))
+}
+
+// searchProductsLike is the portable fallback: case-insensitive substring
+// matching, with name matches ranked above description matches.
+func searchProductsLike(terms []string, page, pageSize int) ([]ProductSearchResult, int64, error) {
+	query := database.DB.Model(&database.Product{})
+	for _, term := range terms {
+		like := "%" + term + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", like, like)
+	}
+	var products []database.Product
+	if err := query.Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+	results := make([]ProductSearchResult, 0, len(products))
+	for _, product := range products {
+		name, description := strings.ToLower(product.Name), strings.ToLower(product.Description)
+		rank := 0.0
+		for _, term := range terms {
+			if strings.Contains(name, term) {
+				rank += 1
+			}
+			if strings.Contains(description, term) {
+				rank += 0.4
+			}
+		}
+		results = append(results, ProductSearchResult{
+			Product:       product,
+			Rank:          rank / float64(len(terms)),
+			NameHighlight: highlightTerms(product.Name, terms),
+			Snippet:       highlightTerms(product.Description, terms),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID < results[j].ID
+	})
+	total := int64(len(results))
+	start := (page - 1) * pageSize
+	if start >= len(results) {
+		return []ProductSearchResult{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(results) {
+		end = len(results)
+	}
+	return results[start:end], total, nil
+}
+
+// searchTerms lower-cases the query and keeps only letters and digits, which
+// also makes the terms safe to embed in a tsquery.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// highlightTerms HTML-escapes text and wraps the terms in it in <mark> tags.
+func highlightTerms(text string, terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	pattern := regexp.MustCompile("(?i)(" + strings.Join(quoted, "|") + ")")
+	var highlighted strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		highlighted.WriteString(html.EscapeString(text[last:match[0]]))
+		highlighted.WriteString(highlightStart + html.EscapeString(text[match[0]:match[1]]) + highlightStop)
+		last = match[1]
+	}
+	highlighted.WriteString(html.EscapeString(text[last:]))
+	return highlighted.String()
+}
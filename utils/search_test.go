@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+func TestHighlightTermsEscapesHTML(t *testing.T) {
+	tests := []struct {
+		text  string
+		terms []string
+		want  string
+	}{
+		{"iPhone 15", []string{"iphone"}, "<mark>iPhone</mark> 15"},
+		{"Fish & Chips", []string{"chips"}, "Fish &amp; <mark>Chips</mark>"},
+		{`<img src=x onerror=alert(1)> phone`, []string{"phone"}, "&lt;img src=x onerror=alert(1)&gt; <mark>phone</mark>"},
+		{"<b>amp</b>", []string{"amp"}, "&lt;b&gt;<mark>amp</mark>&lt;/b&gt;"},
+		{"no match", []string{"zzz"}, "no match"},
+	}
+	for _, tt := range tests {
+		if got := highlightTerms(tt.text, tt.terms); got != tt.want {
+			t.Errorf("highlightTerms(%q, %v) = %q, want %q", tt.text, tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeHeadline(t *testing.T) {
+	headline := "<script>x</script> " + headlineStart + "iPhone" + headlineStop + " 15"
+	want := "&lt;script&gt;x&lt;/script&gt; <mark>iPhone</mark> 15"
+	if got := escapeHeadline(headline); got != want {
+		t.Errorf("escapeHeadline(%q) = %q, want %q", headline, got, want)
+	}
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// Shipment statuses.
+const (
+	ShipmentStatusShipped   = "SHIPPED"
+	ShipmentStatusDelivered = "DELIVERED"
+)
+
+var (
+	ErrOrderNotShippable     = errors.New("only paid orders can be shipped")
+	ErrNothingToShip         = errors.New("every item of the order has already been shipped")
+	ErrInvalidShipmentItem   = errors.New("invalid shipment item")
+	ErrShipmentNotFound      = errors.New("shipment not found")
+	ErrShipmentDelivered     = errors.New("shipment was already delivered")
+	ErrNoShipmentsToDeliver  = errors.New("the order has no shipments waiting for delivery")
+	ErrShipmentTrackingUnset = errors.New("a carrier is required with a tracking number")
+)
+
+// ShipmentLine is a quantity of an order item put in a shipment.
+type ShipmentLine struct {
+	OrderItemID uint `json:"order_item_id" example:"1"`
+	Quantity    int  `json:"quantity" example:"1"`
+}
+
+// ShipmentDetails describes a parcel handed to a carrier. Without lines it
+// holds everything of the order not yet shipped or refunded.
+type ShipmentDetails struct {
+	Carrier        string         `json:"carrier" example:"UPS"`
+	TrackingNumber string         `json:"tracking_number" example:"1Z999AA10123456784"`
+	Items          []ShipmentLine `json:"items"`
+}
+
+// CreateShipment records a shipment of a paid order's items. Once every
+// item is shipped or refunded the order moves to SHIPPED.
+func CreateShipment(orderID uint, details ShipmentDetails, actorID uint) (database.Shipment, error) {
+	details.Carrier = strings.TrimSpace(details.Carrier)
+	details.TrackingNumber = strings.TrimSpace(details.TrackingNumber)
+	if details.TrackingNumber != "" && details.Carrier == "" {
+		return database.Shipment{}, ErrShipmentTrackingUnset
+	}
+	shipment := database.Shipment{
+		OrderID:        orderID,
+		Carrier:        details.Carrier,
+		TrackingNumber: details.TrackingNumber,
+		Status:         ShipmentStatusShipped,
+		ShippedAt:      time.Now(),
+		CreatedBy:      actorID,
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the order so concurrent shipments cannot ship an item twice.
+		var order database.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return fmt.Errorf("failed to load order")
+		}
+		if order.Status != OrderStatusPaid {
+			return fmt.Errorf("%w, the order is %s", ErrOrderNotShippable, order.Status)
+		}
+		items, remaining, err := unshippedQuantities(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		lines := details.Items
+		if len(lines) == 0 {
+			for _, item := range items {
+				if remaining[item.ID] > 0 {
+					lines = append(lines, ShipmentLine{OrderItemID: item.ID, Quantity: remaining[item.ID]})
+				}
+			}
+			if len(lines) == 0 {
+				return ErrNothingToShip
+			}
+		}
+		for _, line := range lines {
+			left, ok := remaining[line.OrderItemID]
+			if !ok || line.Quantity <= 0 {
+				return fmt.Errorf("%w: order item %d", ErrInvalidShipmentItem, line.OrderItemID)
+			}
+			if line.Quantity > left {
+				return fmt.Errorf("%w: only %d of order item %d are left to ship", ErrInvalidShipmentItem, left, line.OrderItemID)
+			}
+			remaining[line.OrderItemID] -= line.Quantity
+			shipment.Items = append(shipment.Items, database.ShipmentItem{OrderItemID: line.OrderItemID, Quantity: line.Quantity})
+		}
+		if err := tx.Create(&shipment).Error; err != nil {
+			return fmt.Errorf("failed to save shipment")
+		}
+		for _, left := range remaining {
+			if left > 0 {
+				return nil
+			}
+		}
+		note := fmt.Sprintf("shipment %d", shipment.ID)
+		if shipment.TrackingNumber != "" {
+			note += fmt.Sprintf(" with %s, tracking %s", shipment.Carrier, shipment.TrackingNumber)
+		}
+		return TransitionOrder(tx, &order, OrderStatusShipped, actorID, note)
+	})
+	return shipment, err
+}
+
+// DeliverShipment marks a shipment delivered. Once every shipment of a
+// fully shipped order is delivered the order moves to DELIVERED.
+func DeliverShipment(orderID uint, shipmentID uint, actorID uint) (database.Shipment, error) {
+	var shipment database.Shipment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND order_id = ?", shipmentID, orderID).First(&shipment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShipmentNotFound
+			}
+			return fmt.Errorf("failed to load shipment")
+		}
+		if shipment.Status == ShipmentStatusDelivered {
+			return ErrShipmentDelivered
+		}
+		if err := markShipmentDelivered(tx, &shipment); err != nil {
+			return err
+		}
+		return completeDelivery(tx, orderID, actorID)
+	})
+	if err != nil {
+		return shipment, err
+	}
+	return shipment, database.DB.Preload("Items").First(&shipment, shipment.ID).Error
+}
+
+// DeliverOrder marks every shipment of an order that is still on its way
+// delivered. Orders shipped before shipments were recorded have none and
+// are delivered as a whole.
+func DeliverOrder(orderID uint, actorID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var order database.Order
+		if err := tx.First(&order, orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return fmt.Errorf("failed to load order")
+		}
+		var shipments []database.Shipment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ? AND status = ?", orderID, ShipmentStatusShipped).Find(&shipments).Error; err != nil {
+			return fmt.Errorf("failed to load shipments")
+		}
+		if len(shipments) == 0 && order.Status != OrderStatusShipped {
+			return ErrNoShipmentsToDeliver
+		}
+		for i := range shipments {
+			if err := markShipmentDelivered(tx, &shipments[i]); err != nil {
+				return err
+			}
+		}
+		return completeDelivery(tx, orderID, actorID)
+	})
+}
+
+// OrderShipments lists the shipments of an order with their items.
+func OrderShipments(db *gorm.DB, orderID uint) ([]database.Shipment, error) {
+	shipments := []database.Shipment{}
+	if err := db.Preload("Items").Where("order_id = ?", orderID).Order("id asc").Find(&shipments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load shipments")
+	}
+	return shipments, nil
+}
+
+func markShipmentDelivered(tx *gorm.DB, shipment *database.Shipment) error {
+	now := time.Now()
+	shipment.Status = ShipmentStatusDelivered
+	shipment.DeliveredAt = &now
+	if err := tx.Model(shipment).Select("status", "delivered_at").Updates(shipment).Error; err != nil {
+		return fmt.Errorf("failed to update shipment")
+	}
+	return nil
+}
+
+// completeDelivery moves a shipped order to DELIVERED when none of its
+// shipments is still on its way.
+func completeDelivery(tx *gorm.DB, orderID uint, actorID uint) error {
+	var order database.Order
+	if err := tx.First(&order, orderID).Error; err != nil {
+		return fmt.Errorf("failed to load order")
+	}
+	if order.Status != OrderStatusShipped {
+		return nil
+	}
+	var open int64
+	if err := tx.Model(&database.Shipment{}).Where("order_id = ? AND status = ?", orderID, ShipmentStatusShipped).Count(&open).Error; err != nil {
+		return fmt.Errorf("failed to load shipments")
+	}
+	if open > 0 {
+		return nil
+	}
+	return TransitionOrder(tx, &order, OrderStatusDelivered, actorID, "order delivered")
+}
+
+// unshippedQuantities returns the order's items and how many of each are
+// neither shipped nor refunded.
+func unshippedQuantities(db *gorm.DB, orderID uint) ([]database.OrderItem, map[uint]int, error) {
+	var items []database.OrderItem
+	if err := db.Where("order_id = ?", orderID).Order("id asc").Find(&items).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load order items")
+	}
+	var shipped []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err := db.Model(&database.ShipmentItem{}).
+		Select("shipment_items.order_item_id, SUM(shipment_items.quantity) AS quantity").
+		Joins("JOIN shipments ON shipments.id = shipment_items.shipment_id").
+		Where("shipments.order_id = ?", orderID).
+		Group("shipment_items.order_item_id").
+		Scan(&shipped).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load shipments")
+	}
+	refunded, err := refundedQuantities(db, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	remaining := map[uint]int{}
+	for _, item := range items {
+		remaining[item.ID] = item.Quantity - refunded[item.ID]
+	}
+	for _, row := range shipped {
+		remaining[row.OrderItemID] -= row.Quantity
+	}
+	for id, left := range remaining {
+		if left < 0 {
+			remaining[id] = 0
+		}
+	}
+	return items, remaining, nil
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"sort"
+)
+
+// Bases of shipping method rate tables.
+const (
+	ShippingBasisWeight = "WEIGHT"
+	ShippingBasisPrice  = "PRICE"
+)
+
+var (
+	ErrShippingMethodNotFound    = errors.New("shipping method not found")
+	ErrShippingMethodUnavailable = errors.New("shipping method is not available for this order")
+	ErrShippingMethodRequired    = errors.New("shipping_method_id is required")
+)
+
+// Parcel is what an order ships: its units' weight and volume, and its value
+// in the base currency after discounts.
+type Parcel struct {
+	WeightGrams int64
+	VolumeMm3   int64
+	Value       database.Money
+}
+
+// Add puts quantity units of product in the parcel.
+func (p *Parcel) Add(product database.Product, quantity int) {
+	p.WeightGrams += int64(product.WeightGrams) * int64(quantity)
+	p.VolumeMm3 += int64(product.LengthMm) * int64(product.WidthMm) * int64(product.HeightMm) * int64(quantity)
+}
+
+// ChargeableWeight is the parcel's weight in grams, or its volumetric
+// weight when the method has a divisor and that is higher.
+func (p Parcel) ChargeableWeight(method database.ShippingMethod) int64 {
+	if method.VolumetricDivisor <= 0 {
+		return p.WeightGrams
+	}
+	// cm³ / divisor gives kilograms, which is mm³ / divisor in grams.
+	if volumetric := p.VolumeMm3 / int64(method.VolumetricDivisor); volumetric > p.WeightGrams {
+		return volumetric
+	}
+	return p.WeightGrams
+}
+
+// ShippingOption is a method that can ship a parcel and its price in the
+// base currency.
+type ShippingOption struct {
+	Method database.ShippingMethod `json:"method"`
+	Price  database.Money          `json:"price"`
+}
+
+// QuoteShipping prices a parcel shipped to country with method, whose rates
+// must be loaded.
+func QuoteShipping(method database.ShippingMethod, country string, parcel Parcel) (database.Money, error) {
+	if !method.Active {
+		return database.Money{}, fmt.Errorf("%w: %s is not active", ErrShippingMethodUnavailable, method.Name)
+	}
+	if len(method.Countries) > 0 && !containsString(method.Countries, country) {
+		return database.Money{}, fmt.Errorf("%w: %s does not ship to %s", ErrShippingMethodUnavailable, method.Name, country)
+	}
+	if method.FreeOver.IsPositive() && method.FreeOver.SameCurrency(parcel.Value) && !parcel.Value.LessThan(method.FreeOver) {
+		return database.NewMoney(0, database.DefaultCurrency()), nil
+	}
+	value := parcel.ChargeableWeight(method)
+	if method.Basis == ShippingBasisPrice {
+		value = parcel.Value.Amount
+	}
+	rates := append([]database.ShippingRate(nil), method.Rates...)
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Min < rates[j].Min })
+	var price *database.Money
+	for i := range rates {
+		if rates[i].Min <= value {
+			price = &rates[i].Price
+		}
+	}
+	if price == nil {
+		return database.Money{}, fmt.Errorf("%w: %s has no rate for this order", ErrShippingMethodUnavailable, method.Name)
+	}
+	return *price, nil
+}
+
+// LoadShippingMethod loads a shipping method with its rates.
+func LoadShippingMethod(db *gorm.DB, id uint) (database.ShippingMethod, error) {
+	var method database.ShippingMethod
+	if err := db.Preload("Rates").First(&method, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return method, ErrShippingMethodNotFound
+		}
+		return method, fmt.Errorf("failed to load shipping method")
+	}
+	return method, nil
+}
+
+// ShippingOptions lists the active methods that can ship the parcel to
+// country, cheapest first.
+func ShippingOptions(db *gorm.DB, country string, parcel Parcel) ([]ShippingOption, error) {
+	var methods []database.ShippingMethod
+	if err := db.Preload("Rates").Where("active = ?", true).Order("id").Find(&methods).Error; err != nil {
+		return nil, fmt.Errorf("failed to load shipping methods")
+	}
+	options := []ShippingOption{}
+	for _, method := range methods {
+		price, err := QuoteShipping(method, country, parcel)
+		if err != nil {
+			continue
+		}
+		options = append(options, ShippingOption{Method: method, Price: price})
+	}
+	sort.SliceStable(options, func(i, j int) bool { return options[i].Price.LessThan(options[j].Price) })
+	return options, nil
+}
+
+// ShippingMethodsConfigured reports whether any shipping method is active.
+// Without one, orders ship for free without choosing a method.
+func ShippingMethodsConfigured(db *gorm.DB) (bool, error) {
+	var count int64
+	if err := db.Model(&database.ShippingMethod{}).Where("active = ?", true).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to load shipping methods")
+	}
+	return count > 0, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
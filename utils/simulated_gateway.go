@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Behaviours of the simulated gateway, selected with SIMULATED_GATEWAY_MODE.
+const (
+	SimulatedModeApprove = "approve"
+	SimulatedModeDecline = "decline"
+	SimulatedModeTimeout = "timeout"
+	SimulatedModeAsync   = "async"
+)
+
+const defaultSimulatedAsyncDelay = 10 * time.Second
+
+type simulatedTransaction struct {
+	id        string
+	status    string
+	amount    database.Money
+	captured  database.Money
+	refunded  database.Money
+	createdAt time.Time
+}
+
+// SimulatedGateway is an in-memory PaymentGateway for development. No money
+// moves; transactions are forgotten when the process restarts.
+type SimulatedGateway struct {
+	Mode       string
+	AsyncDelay time.Duration
+
+	mu           sync.Mutex
+	seq          int
+	transactions map[string]*simulatedTransaction
+}
+
+func NewSimulatedGateway(mode string, asyncDelay time.Duration) *SimulatedGateway {
+	return &SimulatedGateway{
+		Mode:         strings.ToLower(mode),
+		AsyncDelay:   asyncDelay,
+		transactions: map[string]*simulatedTransaction{},
+	}
+}
+
+// NewSimulatedGatewayFromEnv reads SIMULATED_GATEWAY_MODE (approve, decline,
+// timeout or async) and SIMULATED_GATEWAY_ASYNC_DELAY.
+func NewSimulatedGatewayFromEnv() *SimulatedGateway {
+	mode := os.Getenv("SIMULATED_GATEWAY_MODE")
+	if mode == "" {
+		mode = SimulatedModeApprove
+	}
+	return NewSimulatedGateway(mode, durationFromEnv("SIMULATED_GATEWAY_ASYNC_DELAY", defaultSimulatedAsyncDelay))
+}
+
+func (g *SimulatedGateway) Name() string {
+	return "simulated"
+}
+
+func (g *SimulatedGateway) Authorize(req ChargeRequest) (GatewayResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Mode == SimulatedModeTimeout {
+		return GatewayResponse{}, ErrGatewayTimeout
+	}
+	g.seq++
+	txn := &simulatedTransaction{
+		id:        fmt.Sprintf("SIM-%d-%d", req.OrderID, g.seq),
+		amount:    req.Amount,
+		createdAt: time.Now(),
+	}
+	switch g.Mode {
+	case SimulatedModeDecline:
+		txn.status = GatewayStatusDeclined
+		g.transactions[txn.id] = txn
+		return g.response(txn, "card_declined", "the card was declined"), nil
+	case SimulatedModeAsync:
+		txn.status = GatewayStatusPending
+		g.transactions[txn.id] = txn
+		return g.response(txn, "pending_confirmation", "awaiting confirmation from the payment provider"), nil
+	}
+	txn.status = GatewayStatusAuthorized
+	g.transactions[txn.id] = txn
+	return g.response(txn, "approved", "authorization approved"), nil
+}
+
+func (g *SimulatedGateway) Capture(transactionID string, amount database.Money) (GatewayResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	txn, err := g.lookup(transactionID)
+	if err != nil {
+		return GatewayResponse{}, err
+	}
+	if txn.status != GatewayStatusAuthorized || !amount.SameCurrency(txn.amount) || amount.GreaterThan(txn.amount) {
+		return g.response(txn, "invalid_state", ErrInvalidGatewayState.Error()), ErrInvalidGatewayState
+	}
+	txn.captured = amount
+	txn.status = GatewayStatusCaptured
+	return g.response(txn, "captured", "payment captured"), nil
+}
+
+func (g *SimulatedGateway) Void(transactionID string) (GatewayResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	txn, err := g.lookup(transactionID)
+	if err != nil {
+		return GatewayResponse{}, err
+	}
+	if txn.status != GatewayStatusAuthorized && txn.status != GatewayStatusPending {
+		return g.response(txn, "invalid_state", ErrInvalidGatewayState.Error()), ErrInvalidGatewayState
+	}
+	txn.status = GatewayStatusVoided
+	return g.response(txn, "voided", "authorization voided"), nil
+}
+
+func (g *SimulatedGateway) Refund(transactionID string, amount database.Money) (GatewayResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	txn, err := g.lookup(transactionID)
+	if err != nil {
+		return GatewayResponse{}, err
+	}
+	if (txn.status != GatewayStatusCaptured && txn.status != GatewayStatusRefunded) || !amount.IsPositive() ||
+		!amount.SameCurrency(txn.captured) || txn.refunded.Add(amount).GreaterThan(txn.captured) {
+		return g.response(txn, "invalid_state", ErrInvalidGatewayState.Error()), ErrInvalidGatewayState
+	}
+	txn.refunded = txn.refunded.Add(amount)
+	if !txn.refunded.LessThan(txn.captured) {
+		txn.status = GatewayStatusRefunded
+	}
+	resp := g.response(txn, "refunded", "refund processed")
+	resp.Status = GatewayStatusRefunded
+	resp.Amount = amount
+	return resp, nil
+}
+
+// FetchStatus settles pending transactions once AsyncDelay has elapsed, which
+// stands in for the provider's asynchronous confirmation.
+func (g *SimulatedGateway) FetchStatus(transactionID string) (GatewayResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	txn, err := g.lookup(transactionID)
+	if err != nil {
+		return GatewayResponse{}, err
+	}
+	if txn.status == GatewayStatusPending && time.Since(txn.createdAt) >= g.AsyncDelay {
+		txn.status = GatewayStatusCaptured
+		txn.captured = txn.amount
+	}
+	return g.response(txn, strings.ToLower(txn.status), "transaction status"), nil
+}
+
+func (g *SimulatedGateway) lookup(transactionID string) (*simulatedTransaction, error) {
+	txn, ok := g.transactions[transactionID]
+	if !ok {
+		return nil, ErrUnknownTransaction
+	}
+	return txn, nil
+}
+
+func (g *SimulatedGateway) response(txn *simulatedTransaction, code, message string) GatewayResponse {
+	resp := GatewayResponse{
+		TransactionID: txn.id,
+		Status:        txn.status,
+		Amount:        txn.amount,
+		Code:          code,
+		Message:       message,
+	}
+	raw, _ := json.Marshal(map[string]interface{}{
+		"gateway":  g.Name(),
+		"id":       txn.id,
+		"status":   txn.status,
+		"amount":   txn.amount,
+		"captured": txn.captured,
+		"refunded": txn.refunded,
+		"code":     code,
+	})
+	resp.Raw = string(raw)
+	return resp
+}
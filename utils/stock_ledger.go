@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLowStockThreshold      = 5
+	defaultStockReconcileInterval = time.Hour
+)
+
+var ErrInvalidStockMovement = errors.New("invalid stock movement")
+
+// StockChange describes a change of on-hand stock to record with MoveStock.
+// Quantity is signed: positive adds stock, negative takes it.
+type StockChange struct {
+	ProductID uint
+	VariantID uint
+	Type      string
+	Quantity  int
+	Reason    string
+	Reference string
+	ActorID   uint
+}
+
+// StockDiscrepancy is a product or variant whose StockQty does not match the
+// sum of its stock movements.
+type StockDiscrepancy struct {
+	ProductID uint `json:"product_id" example:"1"`
+	VariantID uint `json:"variant_id" example:"0"`
+	StockQty  int  `json:"stock_qty" example:"48"`
+	LedgerQty int  `json:"ledger_qty" example:"50"`
+}
+
+// LowStockThreshold reads LOW_STOCK_THRESHOLD and falls back to 5. Products
+// and variants with their own low_stock_threshold override it.
+func LowStockThreshold() int {
+	threshold, err := strconv.Atoi(os.Getenv("LOW_STOCK_THRESHOLD"))
+	if err != nil || threshold < 0 {
+		return defaultLowStockThreshold
+	}
+	return threshold
+}
+
+// MoveStock applies a change to the on-hand stock of a product or variant and
+// appends it to the stock ledger. Stock never goes below zero. It opens or
+// resolves low-stock alerts when the change crosses the threshold, and should
+// run inside the caller's transaction.
+func MoveStock(tx *gorm.DB, change StockChange) (database.StockMovement, error) {
+	if change.Quantity == 0 || change.Type == database.StockMovementReservationRelease {
+		return database.StockMovement{}, ErrInvalidStockMovement
+	}
+	query := stockQuery(tx, change.ProductID, change.VariantID)
+	if change.Quantity < 0 {
+		query = query.Where("stock_qty >= ?", -change.Quantity)
+	}
+	res := query.Update("stock_qty", gorm.Expr("stock_qty + ?", change.Quantity))
+	if res.Error != nil {
+		return database.StockMovement{}, fmt.Errorf("failed to update product stock")
+	}
+	if res.RowsAffected == 0 {
+		if change.Quantity < 0 {
+			return database.StockMovement{}, ErrInsufficientStock
+		}
+		return database.StockMovement{}, ErrProductNotFound
+	}
+	var row struct {
+		StockQty          int
+		LowStockThreshold int
+	}
+	if err := stockQuery(tx, change.ProductID, change.VariantID).Select("stock_qty", "low_stock_threshold").Scan(&row).Error; err != nil {
+		return database.StockMovement{}, fmt.Errorf("failed to read product stock")
+	}
+	movement := database.StockMovement{
+		ProductID:  change.ProductID,
+		VariantID:  change.VariantID,
+		Type:       change.Type,
+		Quantity:   change.Quantity,
+		StockAfter: row.StockQty,
+		Reason:     change.Reason,
+		Reference:  change.Reference,
+		ActorID:    change.ActorID,
+	}
+	if err := tx.Create(&movement).Error; err != nil {
+		return database.StockMovement{}, fmt.Errorf("failed to record stock movement")
+	}
+	threshold := row.LowStockThreshold
+	if threshold <= 0 {
+		threshold = LowStockThreshold()
+	}
+	if err := checkLowStock(tx, movement, threshold); err != nil {
+		return database.StockMovement{}, err
+	}
+	return movement, nil
+}
+
+// recordReservationRelease logs held stock going back to available. It does
+// not change on-hand stock, so reconciliation ignores it.
+func recordReservationRelease(db *gorm.DB, reservation database.StockReservation, quantity int, reason string) error {
+	var stock []int
+	if err := stockQuery(db, reservation.ProductID, reservation.VariantID).Pluck("stock_qty", &stock).Error; err != nil {
+		return fmt.Errorf("failed to read product stock")
+	}
+	if len(stock) == 0 {
+		return nil
+	}
+	movement := database.StockMovement{
+		ProductID:  reservation.ProductID,
+		VariantID:  reservation.VariantID,
+		Type:       database.StockMovementReservationRelease,
+		Quantity:   quantity,
+		StockAfter: stock[0],
+		Reason:     reason,
+		Reference:  fmt.Sprintf("cart:%d", reservation.CartID),
+	}
+	if err := db.Create(&movement).Error; err != nil {
+		return fmt.Errorf("failed to record stock movement")
+	}
+	return nil
+}
+
+// checkLowStock opens an alert when stock falls to the threshold or below
+// and resolves open alerts once it is back above.
+func checkLowStock(tx *gorm.DB, movement database.StockMovement, threshold int) error {
+	before := movement.StockAfter - movement.Quantity
+	switch {
+	case before > threshold && movement.StockAfter <= threshold:
+		alert := database.StockAlert{
+			ProductID: movement.ProductID,
+			VariantID: movement.VariantID,
+			StockQty:  movement.StockAfter,
+			Threshold: threshold,
+		}
+		if err := tx.Create(&alert).Error; err != nil {
+			return fmt.Errorf("failed to record low stock alert")
+		}
+		log.Printf("low stock: product %d variant %d has %d left (threshold %d)", movement.ProductID, movement.VariantID, movement.StockAfter, threshold)
+	case before <= threshold && movement.StockAfter > threshold:
+		err := tx.Model(&database.StockAlert{}).
+			Where("product_id = ? AND variant_id = ? AND resolved_at IS NULL", movement.ProductID, movement.VariantID).
+			Update("resolved_at", time.Now()).Error
+		if err != nil {
+			return fmt.Errorf("failed to resolve low stock alerts")
+		}
+	}
+	return nil
+}
+
+// ReconcileStock compares the on-hand stock of every product and variant
+// with the sum of its ledger movements and returns the ones that differ.
+func ReconcileStock() ([]StockDiscrepancy, error) {
+	discrepancies := []StockDiscrepancy{}
+	var products []StockDiscrepancy
+	err := database.DB.Table("products").
+		Select("products.id AS product_id, 0 AS variant_id, products.stock_qty, COALESCE(SUM(stock_movements.quantity), 0) AS ledger_qty").
+		Joins("LEFT JOIN stock_movements ON stock_movements.product_id = products.id AND stock_movements.variant_id = 0 AND stock_movements.type <> ?", database.StockMovementReservationRelease).
+		Group("products.id, products.stock_qty").
+		Having("products.stock_qty <> COALESCE(SUM(stock_movements.quantity), 0)").
+		Scan(&products).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile product stock")
+	}
+	var variants []StockDiscrepancy
+	err = database.DB.Table("product_variants").
+		Select("product_variants.product_id, product_variants.id AS variant_id, product_variants.stock_qty, COALESCE(SUM(stock_movements.quantity), 0) AS ledger_qty").
+		Joins("LEFT JOIN stock_movements ON stock_movements.variant_id = product_variants.id AND stock_movements.type <> ?", database.StockMovementReservationRelease).
+		Group("product_variants.id, product_variants.product_id, product_variants.stock_qty").
+		Having("product_variants.stock_qty <> COALESCE(SUM(stock_movements.quantity), 0)").
+		Scan(&variants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile variant stock")
+	}
+	discrepancies = append(discrepancies, products...)
+	return append(discrepancies, variants...), nil
+}
+
+// StartStockReconciler runs ReconcileStock every STOCK_RECONCILE_INTERVAL
+// (1 hour by default) and logs every discrepancy it finds.
+func StartStockReconciler() {
+	interval := durationFromEnv("STOCK_RECONCILE_INTERVAL", defaultStockReconcileInterval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			discrepancies, err := ReconcileStock()
+			if err != nil {
+				log.Println("stock reconciliation failed:", err)
+				continue
+			}
+			for _, d := range discrepancies {
+				log.Printf("stock mismatch: product %d variant %d has stock_qty %d but the ledger sums to %d", d.ProductID, d.VariantID, d.StockQty, d.LedgerQty)
+			}
+		}
+	}()
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownStorage = errors.New("unknown storage driver")
+
+// Storage stores uploaded files under slash separated keys such as
+// "products/1/abc.jpg". Implementations must be safe for concurrent use.
+type Storage interface {
+	Name() string
+	Save(key string, contentType string, r io.Reader) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+var (
+	storageMu        sync.Mutex
+	storageFactories = map[string]func() Storage{
+		"local": func() Storage { return NewLocalStorageFromEnv() },
+	}
+	activeStorage Storage
+)
+
+// RegisterStorage makes a storage backend selectable through STORAGE_DRIVER.
+func RegisterStorage(name string, factory func() Storage) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	storageFactories[strings.ToLower(name)] = factory
+}
+
+// SetStorage overrides the configured storage backend.
+func SetStorage(storage Storage) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	activeStorage = storage
+}
+
+// ActiveStorage returns the backend named by STORAGE_DRIVER ("local" when
+// unset), creating it on first use.
+func ActiveStorage() (Storage, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	if activeStorage != nil {
+		return activeStorage, nil
+	}
+	name := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	if name == "" {
+		name = "local"
+	}
+	factory, ok := storageFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStorage, name)
+	}
+	activeStorage = factory()
+	return activeStorage, nil
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrTaxClassNotFound = errors.New("tax class not found")
+	ErrInvalidTaxZone   = errors.New("country must be a two-letter ISO 3166-1 code")
+)
+
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// taxRateScale is the denominator tax rates are applied with: rates are
+// kept to a ten-thousandth of a per cent.
+const taxRateScale = 1000000
+
+// TaxInclusive reports whether catalog prices include tax
+// (TAX_PRICES_INCLUDE_TAX=true) rather than having it added at checkout.
+func TaxInclusive() bool {
+	inclusive, _ := strconv.ParseBool(os.Getenv("TAX_PRICES_INCLUDE_TAX"))
+	return inclusive
+}
+
+// TaxZone is where an order is taxed: a country and optionally one of its
+// regions (state, province).
+type TaxZone struct {
+	Country string `json:"country" example:"US"`
+	Region  string `json:"region" example:"CA"`
+}
+
+// NewTaxZone normalizes a country and region. A zone without a country has
+// no tax rates.
+func NewTaxZone(country string, region string) (TaxZone, error) {
+	zone := TaxZone{Country: strings.ToUpper(strings.TrimSpace(country)), Region: strings.ToUpper(strings.TrimSpace(region))}
+	if zone.Country != "" && !ValidCountryCode(zone.Country) {
+		return zone, fmt.Errorf("%w: %q", ErrInvalidTaxZone, zone.Country)
+	}
+	return zone, nil
+}
+
+// TaxTable holds the tax rates of every tax class in one zone.
+type TaxTable struct {
+	Zone            TaxZone
+	standardClassID uint
+	classes         map[uint]string
+	rates           map[uint]database.TaxRate
+}
+
+// LoadTaxTable loads the rates that apply in zone, a region's rate taking
+// the place of its country's.
+func LoadTaxTable(db *gorm.DB, zone TaxZone) (TaxTable, error) {
+	table := TaxTable{Zone: zone, classes: map[uint]string{}, rates: map[uint]database.TaxRate{}}
+	var classes []database.TaxClass
+	if err := db.Find(&classes).Error; err != nil {
+		return table, fmt.Errorf("failed to load tax classes")
+	}
+	for _, class := range classes {
+		table.classes[class.ID] = class.Name
+		if class.Name == database.TaxClassStandard {
+			table.standardClassID = class.ID
+		}
+	}
+	if zone.Country == "" {
+		return table, nil
+	}
+	var rates []database.TaxRate
+	err := db.Where("country = ? AND (region = '' OR region = ?)", zone.Country, zone.Region).Order("region asc").Find(&rates).Error
+	if err != nil {
+		return table, fmt.Errorf("failed to load tax rates")
+	}
+	for _, rate := range rates {
+		table.rates[rate.TaxClassID] = rate
+	}
+	return table, nil
+}
+
+// ApplyTax sets the tax class, rate and tax of an order item of product.
+// The item's price and discount must be final.
+func (t TaxTable) ApplyTax(item *database.OrderItem, product database.Product, inclusive bool) {
+	classID := t.standardClassID
+	if product.TaxClassID != nil {
+		classID = *product.TaxClassID
+	}
+	item.TaxClass = t.classes[classID]
+	item.TaxName, item.TaxRate = "", 0
+	if rate, ok := t.rates[classID]; ok {
+		item.TaxName = rate.Name
+		item.TaxRate = rate.Rate
+	}
+	item.Tax = LineTax(item.Price.Mul(item.Quantity).Sub(item.Discount), item.TaxRate, inclusive)
+}
+
+// LineTax is the tax at rate per cent on amount. When inclusive the tax is
+// the part of amount that is tax, otherwise it is added on top of amount.
+func LineTax(amount database.Money, rate float64, inclusive bool) database.Money {
+	scaled := int64(math.Round(rate * taxRateScale / 100))
+	if inclusive {
+		return amount.MulRatio(scaled, taxRateScale+scaled)
+	}
+	return amount.MulRatio(scaled, taxRateScale)
+}
+
+// CheckTaxClass fails when a tax class set on a product does not exist.
+func CheckTaxClass(db *gorm.DB, classID *uint) error {
+	if classID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&database.TaxClass{}).Where("id = ?", *classID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load tax classes")
+	}
+	if count == 0 {
+		return ErrTaxClassNotFound
+	}
+	return nil
+}
+
+// TaxSummaryLine totals the order items taxed at one rate.
+type TaxSummaryLine struct {
+	Name    string         `json:"name" example:"Sales tax"`
+	Rate    float64        `json:"rate" example:"7.25"`
+	Taxable database.Money `json:"taxable"`
+	Tax     database.Money `json:"tax"`
+}
+
+// SummarizeTax groups the items by tax rate, in the order the rates first
+// appear. Taxable is the amount the tax was charged on, net of discounts
+// and, for inclusive prices, of the tax itself.
+func SummarizeTax(items []database.OrderItem, inclusive bool) []TaxSummaryLine {
+	summary := []TaxSummaryLine{}
+	index := map[string]int{}
+	for _, item := range items {
+		key := fmt.Sprintf("%s|%v", item.TaxName, item.TaxRate)
+		i, ok := index[key]
+		if !ok {
+			i = len(summary)
+			index[key] = i
+			summary = append(summary, TaxSummaryLine{Name: item.TaxName, Rate: item.TaxRate})
+		}
+		taxable := item.Price.Mul(item.Quantity).Sub(item.Discount)
+		if inclusive {
+			taxable = taxable.Sub(item.Tax)
+		}
+		summary[i].Taxable = summary[i].Taxable.Add(taxable)
+		summary[i].Tax = summary[i].Tax.Add(item.Tax)
+	}
+	return summary
+}
+
+// ValidCountryCode reports whether country is an upper-case ISO 3166-1
+// alpha-2 code.
+func ValidCountryCode(country string) bool {
+	return countryCodePattern.MatchString(country)
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"testing"
+)
+
+func TestLineTax(t *testing.T) {
+	tests := []struct {
+		amount    int64
+		rate      float64
+		inclusive bool
+		want      int64
+	}{
+		{amount: 10000, rate: 20, want: 2000},
+		{amount: 1999, rate: 8.875, want: 177}, // 177.41
+		{amount: 100, rate: 12.5, want: 12},    // 12.5 rounds to the even 12
+		{amount: 300, rate: 12.5, want: 38},    // 37.5 rounds to the even 38
+		{amount: 10000, rate: 0, want: 0},
+		{amount: 12000, rate: 20, inclusive: true, want: 2000},
+		{amount: 10000, rate: 20, inclusive: true, want: 1667}, // 1666.67
+		{amount: 11900, rate: 19, inclusive: true, want: 1900},
+		{amount: 1999, rate: 8.875, inclusive: true, want: 163}, // 162.96
+		{amount: 10000, rate: 0, inclusive: true, want: 0},
+	}
+	for _, tt := range tests {
+		got := LineTax(database.NewMoney(tt.amount, "USD"), tt.rate, tt.inclusive)
+		if got != database.NewMoney(tt.want, "USD") {
+			t.Errorf("LineTax(%d, %v, inclusive=%v) = %v, want %d USD", tt.amount, tt.rate, tt.inclusive, got, tt.want)
+		}
+	}
+}
+
+func TestLineTaxInclusiveAddsUp(t *testing.T) {
+	// The tax taken out of a tax-inclusive price, added back to the net
+	// price, gives the price; the tax on that net price is the same tax.
+	for _, price := range []int64{1, 99, 1999, 12345, 100000} {
+		gross := database.NewMoney(price, "EUR")
+		tax := LineTax(gross, 21, true)
+		net := gross.Sub(tax)
+		if net.Add(tax) != gross {
+			t.Errorf("net %v + tax %v != %v", net, tax, gross)
+		}
+		if diff := LineTax(net, 21, false).Sub(tax).Amount; diff < -1 || diff > 1 {
+			t.Errorf("exclusive tax on %v differs from inclusive tax %v on %v by %d", net, tax, gross, diff)
+		}
+	}
+}
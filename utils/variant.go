@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MUGISHA-Pascal/Go-Backend-Starter/database"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrVariantRequired = errors.New("this product is sold in variants, choose one with variantId")
+	ErrVariantNotFound = errors.New("variant not found for this product")
+)
+
+// StockItem is what a cart or order line points at: a product, or one of its
+// variants when the product has any. Price and StockQty come from the variant
+// when set, so callers never need to know which one they are dealing with.
+type StockItem struct {
+	Product database.Product
+	Variant *database.ProductVariant
+}
+
+// ResolveStockItem loads the product and, for products sold in variants, the
+// chosen variant. variantID must be zero for products without variants.
+func ResolveStockItem(db *gorm.DB, productID, variantID uint) (StockItem, error) {
+	var item StockItem
+	if err := db.First(&item.Product, productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return item, ErrProductNotFound
+		}
+		return item, fmt.Errorf("failed to load product")
+	}
+	if variantID == 0 {
+		var variants int64
+		if err := db.Model(&database.ProductVariant{}).Where("product_id = ?", productID).Count(&variants).Error; err != nil {
+			return item, fmt.Errorf("failed to load product variants")
+		}
+		if variants > 0 {
+			return item, ErrVariantRequired
+		}
+		return item, nil
+	}
+	var variant database.ProductVariant
+	if err := db.Where("id = ? AND product_id = ?", variantID, productID).First(&variant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return item, ErrVariantNotFound
+		}
+		return item, fmt.Errorf("failed to load product variant")
+	}
+	item.Variant = &variant
+	return item, nil
+}
+
+// VariantID is zero when the item is the product itself.
+func (s StockItem) VariantID() uint {
+	if s.Variant == nil {
+		return 0
+	}
+	return s.Variant.ID
+}
+
+// UnitPrice is the variant's price override or the product's price.
+func (s StockItem) UnitPrice() database.Money {
+	if s.Variant != nil && s.Variant.Price.IsSet() {
+		return s.Variant.Price
+	}
+	return s.Product.Price
+}
+
+// StockQty is the on-hand stock of the variant or the product.
+func (s StockItem) StockQty() int {
+	if s.Variant != nil {
+		return s.Variant.StockQty
+	}
+	return s.Product.StockQty
+}
+
+// Label names the item in messages, e.g. "T-Shirt (color: red, size: M)".
+func (s StockItem) Label() string {
+	if s.Variant == nil || len(s.Variant.Attributes) == 0 {
+		return s.Product.Name
+	}
+	return s.Product.Name + " (" + FormatAttributes(s.Variant.Attributes) + ")"
+}
+
+// FormatAttributes renders variant attributes sorted by name.
+func FormatAttributes(attributes map[string]string) string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+attributes[name])
+	}
+	return strings.Join(parts, ", ")
+}